@@ -0,0 +1,72 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes
+// Shell scripts can distinguish the misuses (i.e. unknown arguments) from the runtime failures by them.
+const (
+	// ExitCodeOK is the exit code for success (also used by the auto flags such as help and version)
+	ExitCodeOK = 0
+	// ExitCodeError is the exit code for the handler errors and the other failures
+	ExitCodeError = 1
+	// ExitCodeUsage is the exit code for the usage errors (i.e. flag, env, config and prompt errors)
+	ExitCodeUsage = 2
+	// ExitCodeTimeout is the exit code for the command timeouts (see Cmd.Timeout)
+	ExitCodeTimeout = 124
+)
+
+// ExitError represents an error with an exit code
+// Handlers can return it for controlling the exit code. Silent errors are not printed.
+type ExitError struct {
+	// Code is the exit code
+	Code int
+	// Message is the error message. Default is the message of Err
+	Message string
+	// Silent disables printing the error
+	Silent bool
+	// Err is the underlying error if any
+	Err error
+}
+
+// Error returns the error message
+func (e *ExitError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	} else if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the given error
+// It returns ExitCodeOK for nil, the code of ExitError and ExitCodeError for the other errors.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	var e *ExitError
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ExitCodeError
+}
+
+// fail prints the given error unless it's silent and returns its exit code
+func (cmd *Cmd) fail(err error) int {
+	var e *ExitError
+	if !errors.As(err, &e) || !e.Silent {
+		cmd.logger.Printf("%s\n", err)
+	}
+	return ExitCode(err)
+}
@@ -21,6 +21,7 @@ type Arg struct {
 	valueID    int
 	indexFrom  int
 	indexTo    int
+	source     string   // response file and line (i.e. `args.txt:3`)
 	updatedBy  []string // for debug
 	err        error
 }
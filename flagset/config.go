@@ -0,0 +1,335 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	configNameRegexp = regexp.MustCompile("^[a-zA-Z0-9-_.]+$")
+)
+
+// configEntry represents a configuration entry (i.e. `key = value`)
+type configEntry struct {
+	section string
+	key     string
+	values  []string
+	isArray bool
+	file    string
+	line    int
+}
+
+// position returns the position of the configuration entry (i.e. `app.ini:3`)
+func (ce *configEntry) position() string {
+	return fmt.Sprintf("%s:%d", ce.file, ce.line)
+}
+
+// parseConfigFiles parses the given configuration files and updates the flag configuration entries
+// Later files override the earlier ones.
+func (flagSet *FlagSet) parseConfigFiles(files []string) error {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to read config file due to %s", err.Error())
+		}
+		entries, err := parseConfig(file, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		// Iterate over the entries and match them with the flags
+		for _, entry := range entries {
+			flag, err := flagSet.flagByConfigKey(entry.section, entry.key)
+			if err != nil {
+				return fmt.Errorf("%s: %s", entry.position(), err.Error())
+			}
+			if entry.isArray && !strings.HasPrefix(flag.valueType, "[]") {
+				return fmt.Errorf("%s: key %s can't have an array value", entry.position(), entry.key)
+			}
+			if flagSet.configs == nil {
+				flagSet.configs = make(map[int]*configEntry)
+			}
+			flagSet.configs[flag.id] = entry // last one wins
+		}
+	}
+
+	return nil
+}
+
+// flagByConfigKey returns an argument flag by the given configuration section and key
+// Sections are command names separated by dot (i.e. `[math.pow]`) and keys are argument names (i.e. `base = 2`).
+func (flagSet *FlagSet) flagByConfigKey(section, key string) (*Flag, error) {
+	// Find the command
+	parentID := -1
+	if section != "" {
+		for _, command := range strings.Split(section, ".") {
+			found := false
+			for _, flag := range flagSet.flags {
+				if flag.kind == "command" && flag.parentID == parentID && flag.command == command {
+					parentID = flag.id
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown section [%s]", section)
+			}
+		}
+	}
+
+	// Find the argument
+	for _, flag := range flagSet.flags {
+		if flag.kind == "arg" && flag.parentID == parentID && (flag.long == key || flag.short == key) {
+			return flag, nil
+		}
+	}
+	if section != "" {
+		return nil, fmt.Errorf("unknown key %s in [%s] section", key, section)
+	}
+	return nil, fmt.Errorf("unknown key %s", key)
+}
+
+// parseConfig parses the given configuration content and returns the list of the entries
+// It supports a subset of INI and TOML formats; `[section]` headers, `key = value` pairs,
+// quoted and bare strings, numbers, booleans, arrays and comments (`#` and `;`).
+func parseConfig(name string, r io.Reader) ([]*configEntry, error) {
+	// Init vars
+	var result []*configEntry
+	section := ""
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	// Iterate over the lines
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		// Section (i.e. `[math.pow]`)
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: invalid section", name, lineNum)
+			}
+			s := strings.TrimSpace(line[1 : len(line)-1])
+			if !configNameRegexp.MatchString(s) {
+				return nil, fmt.Errorf("%s:%d: invalid section name %s", name, lineNum, line)
+			}
+			section = s
+			continue
+		}
+
+		// Key and value (i.e. `base = 2`)
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s:%d: invalid line", name, lineNum)
+		}
+		key := strings.TrimSpace(kv[0])
+		if !configNameRegexp.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid key %s", name, lineNum, key)
+		}
+		value := strings.TrimSpace(kv[1])
+
+		// Multi-line arrays
+		entryLine := lineNum
+		if strings.HasPrefix(value, "[") {
+			for !isConfigArrayClosed(value) {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("%s:%d: unterminated array for key %s", name, entryLine, key)
+				}
+				lineNum++
+				value = fmt.Sprintf("%s %s", value, strings.TrimSpace(stripConfigComment(scanner.Text())))
+			}
+		}
+
+		entry := configEntry{
+			section: section,
+			key:     key,
+			file:    name,
+			line:    entryLine,
+		}
+		var err error
+		if strings.HasPrefix(value, "[") {
+			entry.isArray = true
+			entry.values, err = parseConfigArray(value)
+		} else {
+			var v string
+			v, err = parseConfigValue(value)
+			entry.values = []string{v}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s for key %s", name, entryLine, err.Error(), key)
+		}
+		result = append(result, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file due to %s", err.Error())
+	}
+
+	return result, nil
+}
+
+// parseConfigValue parses the given configuration value and returns it as a string
+func parseConfigValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	// Quoted strings
+	if value[0] == '"' || value[0] == '\'' {
+		v, rest, err := unquoteConfigValue(value)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return "", errors.New("unexpected characters after string")
+		}
+		return v, nil
+	}
+
+	// Bare strings, numbers and booleans
+	if strings.ContainsAny(value, "\"'") {
+		return "", errors.New("invalid value")
+	}
+	return value, nil
+}
+
+// parseConfigArray parses the given configuration array (i.e. `[1, 2, "three"]`) and returns the list of the values
+func parseConfigArray(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, errors.New("invalid array")
+	}
+
+	// Init vars
+	result := []string{}
+	rest := strings.TrimSpace(value[1 : len(value)-1])
+
+	// Iterate over the items
+	for rest != "" {
+		item := ""
+		if rest[0] == '"' || rest[0] == '\'' {
+			v, r, err := unquoteConfigValue(rest)
+			if err != nil {
+				return nil, err
+			}
+			item, rest = v, strings.TrimSpace(r)
+			if rest != "" && rest[0] != ',' {
+				return nil, errors.New("unexpected characters after string")
+			}
+		} else {
+			i := strings.Index(rest, ",")
+			if i == -1 {
+				i = len(rest)
+			}
+			item = strings.TrimSpace(rest[:i])
+			rest = rest[i:]
+			if strings.HasPrefix(item, "[") {
+				return nil, errors.New("nested arrays are not supported")
+			} else if strings.ContainsAny(item, "[]\"'") {
+				return nil, errors.New("invalid array item")
+			}
+			if item == "" {
+				return nil, errors.New("empty array item")
+			}
+		}
+		result = append(result, item)
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+	}
+
+	return result, nil
+}
+
+// unquoteConfigValue unquotes the given quoted value and returns the value and the rest of the content
+// Double quoted strings support escape sequences (i.e. `\"`, `\\`, `\n`, `\t`) and single quoted ones are literal.
+func unquoteConfigValue(value string) (string, string, error) {
+	quote := value[0]
+	var sb strings.Builder
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		if c == quote {
+			return sb.String(), value[i+1:], nil
+		}
+		if c == '\\' && quote == '"' {
+			i++
+			if i == len(value) {
+				break
+			}
+			switch value[i] {
+			case '\\', '"':
+				sb.WriteByte(value[i])
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				return "", "", errors.New("invalid escape sequence")
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// stripConfigComment removes the comment from the given line
+// Comments start with `#` or `;` at the beginning of the line or after a whitespace and outside of quotes.
+func stripConfigComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' || c == ';':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// isConfigArrayClosed returns whether the given array value has the closing bracket or not
+func isConfigArrayClosed(value string) bool {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	p := filepath.Join(t.TempDir(), name)
+	writeTestFileAt(t, p, content)
+	return p
+}
+
+func writeTestFileAt(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNew_configFiles(t *testing.T) {
+	Convey("should set the flag values by the config files", t, func() {
+		config := writeTestFile(t, "app.ini", `
+# Top level arguments
+verbose = true
+name = "foo \"bar\""  ; inline comment
+tags = ["a", 'b#c', d]
+ids = [
+  1, 2, # first ones
+  3,
+]
+
+[math]
+precision = 3
+
+[math.pow]
+base = 2.5
+e = -1
+`)
+		flags := struct {
+			Verbose bool     `long:"verbose"`
+			Name    string   `long:"name"`
+			Tags    []string `long:"tags"`
+			IDs     []int    `long:"ids"`
+			Math    struct {
+				Precision int `long:"precision" default:"2"`
+				Pow       struct {
+					Base     float64 `short:"b" long:"base" required:"true"`
+					Exponent float64 `short:"e" long:"exponent" required:"true"`
+				} `command:"pow"`
+			} `command:"math"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "math", "pow"}, ConfigFiles: []string{config}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Verbose, ShouldEqual, true)
+		So(flags.Name, ShouldEqual, `foo "bar"`)
+		So(flags.Tags, ShouldResemble, []string{"a", "b#c", "d"})
+		So(flags.IDs, ShouldResemble, []int{1, 2, 3})
+		So(flags.Math.Precision, ShouldEqual, 3)
+		So(flags.Math.Pow.Base, ShouldEqual, 2.5)
+		So(flags.Math.Pow.Exponent, ShouldEqual, -1)
+		So(flagSet.FlagByName("Math.Pow.Base").ValueBy(), ShouldEqual, "config")
+	})
+
+	Convey("should respect the precedence of the values", t, func() {
+		os.Setenv("GOCMD_TEST_CONFIG_ENV", "env")
+		defer os.Unsetenv("GOCMD_TEST_CONFIG_ENV")
+
+		config1 := writeTestFile(t, "app1.ini", "arg = config\nenv = config\nconfig = config1\n")
+		config2 := writeTestFile(t, "app2.ini", "config = config2\nlist = a|b\n")
+		flags := struct {
+			Arg     string   `long:"arg" default:"default"`
+			Env     string   `long:"env" env:"GOCMD_TEST_CONFIG_ENV" default:"default"`
+			Config  string   `long:"config" default:"default"`
+			Default string   `long:"default" default:"default"`
+			List    []string `long:"list" delimiter:"|"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "--arg=arg"}, ConfigFiles: []string{config1, config2}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Arg, ShouldEqual, "arg")
+		So(flags.Env, ShouldEqual, "env")
+		So(flags.Config, ShouldEqual, "config2")
+		So(flags.Default, ShouldEqual, "default")
+		So(flags.List, ShouldResemble, []string{"a", "b"})
+	})
+
+	Convey("should return the config value errors with line numbers", t, func() {
+		config := writeTestFile(t, "app.ini", "\n[pow]\nbase = two\n")
+		flags := struct {
+			Pow struct {
+				Base float64 `long:"base"`
+			} `command:"pow"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "pow"}, ConfigFiles: []string{config}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldContain, errors.New(config+":3: failed to parse 'two' as float64"))
+	})
+
+	Convey("should fail to parse the config files", t, func() {
+		flags := struct {
+			Foo  string `long:"foo"`
+			Bar  bool   `long:"bar"`
+			Math struct {
+				Base float64 `long:"base"`
+			} `command:"math"`
+		}{}
+
+		tests := []struct {
+			content string
+			err     string
+		}{
+			{content: "foo", err: ":1: invalid line"},
+			{content: "\n[math", err: ":2: invalid section"},
+			{content: "[math pow]", err: ":1: invalid section name [math pow]"},
+			{content: "[math.pow]\nbase = 1", err: ":2: unknown section [math.pow]"},
+			{content: "[math]\nqux = 1", err: ":2: unknown key qux in [math] section"},
+			{content: "qux = 1", err: ":1: unknown key qux"},
+			{content: "foo bar = 1", err: ":1: invalid key foo bar"},
+			{content: `foo = "bar`, err: ":1: unterminated string for key foo"},
+			{content: `foo = "bar" baz`, err: ":1: unexpected characters after string for key foo"},
+			{content: `foo = "\x"`, err: ":1: invalid escape sequence for key foo"},
+			{content: `foo = b"ar`, err: ":1: invalid value for key foo"},
+			{content: `password = "s3cr3t"x`, err: ":1: unexpected characters after string for key password"},
+			{content: "foo = [a,\nb", err: ":1: unterminated array for key foo"},
+			{content: "foo = [a, , b]", err: ":1: empty array item for key foo"},
+			{content: "foo = [a, [b]]", err: ":1: nested arrays are not supported for key foo"},
+			{content: "\n\nbar = [true]", err: ":3: key bar can't have an array value"},
+		}
+		for _, v := range tests {
+			config := writeTestFile(t, "app.ini", v.content)
+			flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}, ConfigFiles: []string{config}})
+			So(err, ShouldBeError, errors.New(config+v.err))
+			So(flagSet, ShouldBeNil)
+		}
+
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}, ConfigFiles: []string{"/missing/app.ini"}})
+		So(err, ShouldBeError, errors.New("failed to read config file due to open /missing/app.ini: no such file or directory"))
+		So(flagSet, ShouldBeNil)
+	})
+}
@@ -0,0 +1,238 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	envNameRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_.]*$")
+)
+
+// parseEnvFiles parses the given dotenv files and updates the environment variables of the flag set
+// The process environment is not modified. Later files override the earlier ones.
+func (flagSet *FlagSet) parseEnvFiles(files []string) error {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to read env file due to %s", err.Error())
+		}
+		err = parseDotenv(file, f, flagSet.lookupEnv, func(k, v string) {
+			if flagSet.envs == nil {
+				flagSet.envs = make(map[string]string)
+			}
+			flagSet.envs[k] = v
+		})
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv returns the value of the given environment variable
+// Environment variables override the ones in the env files.
+func (flagSet *FlagSet) lookupEnv(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	if v, ok := flagSet.envs[name]; ok {
+		return v, true
+	}
+	return "", false
+}
+
+// parseDotenv parses the given dotenv content and calls the set function for each variable
+// It supports comments, `export` prefix, single quoted (literal), double quoted (escaped) and
+// unquoted values, and `${VAR}`, `${VAR:-default}` and `$VAR` interpolation by the lookup function.
+func parseDotenv(name string, r io.Reader, lookup func(string) (string, bool), set func(string, string)) error {
+	// Init vars
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	vars := map[string]string{}
+	lookupVar := func(k string) (string, bool) {
+		if v, ok := lookup(k); ok {
+			return v, true
+		}
+		v, ok := vars[k]
+		return v, ok
+	}
+
+	// Iterate over the lines
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Export prefix (i.e. `export FOO=bar`)
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+
+		// Key and value
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("%s:%d: invalid line", name, lineNum)
+		}
+		key := strings.TrimSpace(kv[0])
+		if !envNameRegexp.MatchString(key) {
+			return fmt.Errorf("%s:%d: invalid variable name %s", name, lineNum, key)
+		}
+		value, err := parseDotenvValue(strings.TrimSpace(kv[1]), lookupVar)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s for variable %s", name, lineNum, err.Error(), key)
+		}
+		vars[key] = value
+		set(key, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read env file due to %s", err.Error())
+	}
+
+	return nil
+}
+
+// parseDotenvValue parses the given dotenv value
+func parseDotenvValue(value string, lookup func(string) (string, bool)) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	// Single quoted values are literal
+	if value[0] == '\'' {
+		i := strings.Index(value[1:], "'")
+		if i == -1 {
+			return "", errors.New("unterminated string")
+		}
+		if rest := strings.TrimSpace(value[i+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", errors.New("unexpected characters after string")
+		}
+		return value[1 : i+1], nil
+	}
+
+	// Double quoted values support escape sequences and interpolation
+	if value[0] == '"' {
+		var sb strings.Builder
+		var literal []bool // by escaped dollar signs
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '"':
+				if rest := strings.TrimSpace(value[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+					return "", errors.New("unexpected characters after string")
+				}
+				return interpolateDotenvValue(sb.String(), literal, lookup)
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				case 'r':
+					sb.WriteByte('\r')
+				case '$':
+					literal = markDotenvLiteral(literal, sb.Len())
+					sb.WriteByte('$')
+				default:
+					sb.WriteByte(value[i])
+				}
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated string")
+	}
+
+	// Unquoted values (inline comments must be preceded by a whitespace)
+	if i := strings.Index(value, " #"); i > -1 {
+		value = value[:i]
+	}
+	if i := strings.Index(value, "\t#"); i > -1 {
+		value = value[:i]
+	}
+	var sb strings.Builder
+	var literal []bool // by escaped dollar signs
+	value = strings.TrimSpace(value)
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) && value[i+1] == '$' {
+			literal = markDotenvLiteral(literal, sb.Len())
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return interpolateDotenvValue(sb.String(), literal, lookup)
+}
+
+// markDotenvLiteral marks the dollar sign at the given position as literal and returns the marks
+func markDotenvLiteral(literal []bool, pos int) []bool {
+	for len(literal) <= pos {
+		literal = append(literal, false)
+	}
+	literal[pos] = true
+	return literal
+}
+
+// interpolateDotenvValue replaces the variables (i.e. `${VAR}`, `${VAR:-default}`, `$VAR`) in the given value
+// The dollar signs those are marked as literal (i.e. escaped by `\$`) are not replaced.
+func interpolateDotenvValue(value string, literal []bool, lookup func(string) (string, bool)) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '$' || i+1 == len(value) || (i < len(literal) && literal[i]) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		// Braced variables (i.e. `${VAR}`, `${VAR:-default}`)
+		if value[i+1] == '{' {
+			j := strings.Index(value[i:], "}")
+			if j == -1 {
+				return "", errors.New("unterminated variable reference")
+			}
+			expr := value[i+2 : i+j]
+			def := ""
+			if k := strings.Index(expr, ":-"); k > -1 {
+				expr, def = expr[:k], expr[k+2:]
+			}
+			if !envNameRegexp.MatchString(expr) {
+				return "", errors.New("invalid variable reference")
+			}
+			if v, ok := lookup(expr); ok && v != "" {
+				sb.WriteString(v)
+			} else {
+				sb.WriteString(def)
+			}
+			i += j
+			continue
+		}
+
+		// Plain variables (i.e. `$VAR`)
+		j := i + 1
+		for j < len(value) && (value[j] == '_' || (value[j] >= 'a' && value[j] <= 'z') || (value[j] >= 'A' && value[j] <= 'Z') || (j > i+1 && value[j] >= '0' && value[j] <= '9')) {
+			j++
+		}
+		if j == i+1 {
+			sb.WriteByte(c) // not a variable (i.e. `$1`, `$ `)
+			continue
+		}
+		if v, ok := lookup(value[i+1 : j]); ok {
+			sb.WriteString(v)
+		}
+		i = j - 1
+	}
+
+	return sb.String(), nil
+}
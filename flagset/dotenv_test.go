@@ -0,0 +1,105 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNew_envFiles(t *testing.T) {
+	Convey("should set the flag values by the env files", t, func() {
+		os.Setenv("GOCMD_TEST_DOTENV_HOST", "example.com")
+		defer os.Unsetenv("GOCMD_TEST_DOTENV_HOST")
+
+		envFile1 := writeTestFile(t, ".env", `
+# Comment
+export GOCMD_TEST_DOTENV_USER=foo
+GOCMD_TEST_DOTENV_PASS = 'p@ss $word' # comment
+GOCMD_TEST_DOTENV_URL="https://${GOCMD_TEST_DOTENV_USER}@${GOCMD_TEST_DOTENV_HOST}/\$path"
+GOCMD_TEST_DOTENV_PORT=${GOCMD_TEST_DOTENV_MISSING:-8080}
+GOCMD_TEST_DOTENV_LIST=a,b #c
+`)
+		envFile2 := writeTestFile(t, ".env.local", "GOCMD_TEST_DOTENV_USER=bar\nGOCMD_TEST_DOTENV_HOST=example.org\nGOCMD_TEST_DOTENV_MSG=\"hello\\n$GOCMD_TEST_DOTENV_USER\"")
+		flags := struct {
+			User string   `long:"user" env:"GOCMD_TEST_DOTENV_USER"`
+			Pass string   `long:"pass" env:"GOCMD_TEST_DOTENV_PASS"`
+			URL  string   `long:"url" env:"GOCMD_TEST_DOTENV_URL"`
+			Port int      `long:"port" env:"GOCMD_TEST_DOTENV_PORT"`
+			List []string `long:"list" env:"GOCMD_TEST_DOTENV_LIST" delimiter:","`
+			Host string   `long:"host" env:"GOCMD_TEST_DOTENV_HOST"`
+			Msg  string   `long:"msg" env:"GOCMD_TEST_DOTENV_MSG"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}, EnvFiles: []string{envFile1, envFile2}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.User, ShouldEqual, "bar")
+		So(flags.Pass, ShouldEqual, "p@ss $word")
+		So(flags.URL, ShouldEqual, "https://foo@example.com/$path")
+		So(flags.Port, ShouldEqual, 8080)
+		So(flags.List, ShouldResemble, []string{"a", "b"})
+		So(flags.Host, ShouldEqual, "example.com") // environment variables override env files
+		So(flags.Msg, ShouldEqual, "hello\nbar")
+		So(flagSet.FlagByName("User").ValueBy(), ShouldEqual, "env")
+
+		// Process environment must not be modified
+		_, ok := os.LookupEnv("GOCMD_TEST_DOTENV_USER")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("should not interpolate the escaped dollar signs only", t, func() {
+		envFile := writeTestFile(t, ".env", `
+GOCMD_TEST_DOTENV_HOME=/home/u
+GOCMD_TEST_DOTENV_A="x\\$GOCMD_TEST_DOTENV_HOME"
+GOCMD_TEST_DOTENV_B="x\$GOCMD_TEST_DOTENV_HOME"
+GOCMD_TEST_DOTENV_C=x\$GOCMD_TEST_DOTENV_HOME
+`)
+		flags := struct {
+			A string `long:"a" env:"GOCMD_TEST_DOTENV_A"`
+			B string `long:"b" env:"GOCMD_TEST_DOTENV_B"`
+			C string `long:"c" env:"GOCMD_TEST_DOTENV_C"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}, EnvFiles: []string{envFile}})
+		So(err, ShouldBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.A, ShouldEqual, `x\/home/u`)
+		So(flags.B, ShouldEqual, "x$GOCMD_TEST_DOTENV_HOME")
+		So(flags.C, ShouldEqual, "x$GOCMD_TEST_DOTENV_HOME")
+	})
+
+	Convey("should fail to parse the env files", t, func() {
+		flags := struct {
+			Foo string `long:"foo" env:"FOO"`
+		}{}
+
+		tests := []struct {
+			content string
+			err     string
+		}{
+			{content: "FOO", err: ":1: invalid line"},
+			{content: "\n1FOO=bar", err: ":2: invalid variable name 1FOO"},
+			{content: "FOO='bar", err: ":1: unterminated string for variable FOO"},
+			{content: `FOO="bar`, err: ":1: unterminated string for variable FOO"},
+			{content: `FOO="bar" baz`, err: ":1: unexpected characters after string for variable FOO"},
+			{content: "FOO=${BAR", err: ":1: unterminated variable reference for variable FOO"},
+			{content: "FOO=${B-R}", err: ":1: invalid variable reference for variable FOO"},
+			{content: "TOKEN='s3cr3t'x", err: ":1: unexpected characters after string for variable TOKEN"},
+		}
+		for _, v := range tests {
+			envFile := writeTestFile(t, ".env", v.content)
+			flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}, EnvFiles: []string{envFile}})
+			So(err, ShouldBeError, errors.New(envFile+v.err))
+			So(flagSet, ShouldBeNil)
+		}
+
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}, EnvFiles: []string{"/missing/.env"}})
+		So(err, ShouldBeError, errors.New("failed to read env file due to open /missing/.env: no such file or directory"))
+		So(flagSet, ShouldBeNil)
+	})
+}
@@ -5,6 +5,7 @@ package flagset
 
 import (
 	"fmt"
+	"time"
 )
 
 var (
@@ -57,6 +58,10 @@ type Flag struct {
 	global          bool
 	delimiter       string
 	env             string
+	envDisabled     bool   // `env:"-"` disables the automatic environment variable binding
+	file            bool   // the value can be read from a file (i.e. `--password @/path/file`)
+	secret          bool   // the value must not be exposed
+	timeout         string // the handler timeout of the command (i.e. `timeout:"30s"`)
 	valueDefault    string
 	valueType       string
 	valueBy         string
@@ -137,6 +142,25 @@ func (f *Flag) Env() string {
 	return f.env
 }
 
+// Secret returns whether the flag value is secret or not
+func (f *Flag) Secret() bool {
+	return f.secret
+}
+
+// Timeout returns the handler timeout of the command or returns zero if it's not set
+func (f *Flag) Timeout() time.Duration {
+	d, err := time.ParseDuration(f.timeout)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// File returns whether the flag value can be read from a file or not (i.e. `--password @/path/file`)
+func (f *Flag) File() bool {
+	return f.file
+}
+
 // Delimiter returns the delimiter value of the flag
 func (f *Flag) Delimiter() string {
 	return f.delimiter
@@ -6,6 +6,7 @@ package flagset_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/devfacet/gocmd/v3/flagset"
 	. "github.com/smartystreets/goconvey/convey"
@@ -186,6 +187,67 @@ func TestFlag_Env(t *testing.T) {
 	})
 }
 
+func TestFlag_Secret(t *testing.T) {
+	Convey("should return the secret value of the flag", t, func() {
+		flags := struct {
+			Test string `short:"f" secret:"true"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		flag := flagSet.FlagByName("Test")
+		So(flag, ShouldNotBeNil)
+		So(flag.Secret(), ShouldEqual, true)
+	})
+}
+
+func TestFlag_Timeout(t *testing.T) {
+	Convey("should return the timeout value of the flag", t, func() {
+		flags := struct {
+			Test struct {
+				Foo bool `short:"f"`
+			} `command:"test" timeout:"1m30s"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		flag := flagSet.FlagByName("Test")
+		So(flag, ShouldNotBeNil)
+		So(flag.Timeout(), ShouldEqual, 90*time.Second)
+		flag = flagSet.FlagByName("Test.Foo")
+		So(flag, ShouldNotBeNil)
+		So(flag.Timeout(), ShouldEqual, 0)
+	})
+
+	Convey("should fail to parse the timeout value of the flag", t, func() {
+		flagSet, err := flagset.New(flagset.Options{Flags: &struct {
+			Test struct{} `command:"test" timeout:"soon"`
+		}{}})
+		So(err, ShouldBeError, errors.New("invalid timeout soon in Test field"))
+		So(flagSet, ShouldBeNil)
+
+		flagSet, err = flagset.New(flagset.Options{Flags: &struct {
+			Test bool `short:"t" timeout:"1s"`
+		}{}})
+		So(err, ShouldBeError, errors.New("timeout tag in Test field is only supported by commands"))
+		So(flagSet, ShouldBeNil)
+	})
+}
+
+func TestFlag_File(t *testing.T) {
+	Convey("should return the file value of the flag", t, func() {
+		flags := struct {
+			Test string `short:"f" file:"true"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		flag := flagSet.FlagByName("Test")
+		So(flag, ShouldNotBeNil)
+		So(flag.File(), ShouldEqual, true)
+	})
+}
+
 func TestFlag_Delimiter(t *testing.T) {
 	Convey("should return the delimiter value of the flag", t, func() {
 		flags := struct {
@@ -13,8 +13,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// SecretMask is the mask that is used instead of the secret flag values (i.e. `secret:"true"`)
+const SecretMask = "******"
+
 // Options represents the options that can be set when creating a new flag set
 type Options struct {
 	// Flags represent the user defined command line arguments and commands.
@@ -22,6 +26,30 @@ type Options struct {
 	Flags interface{}
 	// Args hold command line arguments. Default is os.Args
 	Args []string
+	// ConfigFiles hold the configuration file paths (INI/TOML subset).
+	// Sections are command names (i.e. `[math.pow]`) and keys are argument names (i.e. `base = 2`).
+	// Configuration values override default values but not environment variables and arguments.
+	// Later files override the earlier ones.
+	ConfigFiles []string
+	// EnvFiles hold the dotenv file paths. Their variables are used for the flags those have env tags.
+	// The process environment is not modified and its variables override the ones in the files.
+	// Later files override the earlier ones.
+	EnvFiles []string
+	// EnvPrefix enables the automatic environment variable binding for the argument flags those have no env tag.
+	// Variable names are derived from the prefix and the flag names (i.e. MYAPP_MATH_POW_BASE for Math.Pow.Base
+	// and MYAPP_MATH_MAX_RETRIES for Math.MaxRetries)
+	// Use `env:"-"` tag for disabling it.
+	EnvPrefix string
+	// ResponseFiles enables the response file arguments (i.e. `@args.txt`).
+	// They are replaced by the arguments in the files (separated by whitespaces or new lines, quoted by the shell rules).
+	// Use `@@` for the arguments those start with `@` (i.e. `@@foo` for `@foo`).
+	ResponseFiles bool
+	// Prompt is called for the missing required arguments (of the present commands) for reading their values.
+	// When the value is not valid, it's called again with the error. Returning false stops prompting.
+	Prompt func(flag *Flag, err error) (string, bool)
+
+	// tokenized is set when the arguments are already unquoted by Split (see ParseString)
+	tokenized bool
 }
 
 // New returns a flag set by the given options
@@ -40,8 +68,9 @@ func New(o Options) (*FlagSet, error) {
 
 	// Init vars
 	flagSet := FlagSet{
-		flagsRaw: o.Flags,
-		argsRaw:  make([]string, len(o.Args)),
+		flagsRaw:  o.Flags,
+		argsRaw:   make([]string, len(o.Args)),
+		tokenized: o.tokenized,
 	}
 	copy(flagSet.argsRaw, o.Args) // make a copy
 
@@ -53,6 +82,24 @@ func New(o Options) (*FlagSet, error) {
 			return nil, errs[0] // return the first error
 		}
 	}
+	if o.EnvPrefix != "" {
+		flagSet.bindEnv(o.EnvPrefix)
+	}
+	if len(o.EnvFiles) > 0 {
+		if err := flagSet.parseEnvFiles(o.EnvFiles); err != nil {
+			return nil, err
+		}
+	}
+	if len(o.ConfigFiles) > 0 {
+		if err := flagSet.parseConfigFiles(o.ConfigFiles); err != nil {
+			return nil, err
+		}
+	}
+	if o.ResponseFiles {
+		if err := flagSet.expandResponseFiles(); err != nil {
+			return nil, err
+		}
+	}
 	flagSet.parseCommands()
 	flagSet.parseArgs()
 	flagSet.parseSettings()
@@ -103,9 +150,22 @@ func New(o Options) (*FlagSet, error) {
 				continue // do not continue if the argument has an error
 			}
 
+			// Read the value from the file (i.e. `--password @/path/file`)
+			value := arg.value
+			valueFile := ""
+			if flag.file && strings.HasPrefix(value, "@") {
+				valueFile = strings.TrimPrefix(value, "@")
+				v, err := readFileValue(valueFile)
+				if err != nil {
+					arg.err = fmt.Errorf("failed to read file %s for argument %s%s due to %s", valueFile, arg.dash, arg.name, err.Error())
+					continue
+				}
+				value = v
+			}
+
 			// Update the flag value
 			if flag.delimiter != "" && strings.HasPrefix(flag.valueType, "[]") {
-				values := strings.Split(arg.value, flag.delimiter)
+				values := strings.Split(value, flag.delimiter)
 				for _, v := range values {
 					// Ignore empty ones
 					v = strings.TrimSpace(v)
@@ -117,10 +177,14 @@ func New(o Options) (*FlagSet, error) {
 					}
 				}
 			} else {
-				if err := flagSet.setFlag(flag.id, arg.value); err != nil {
+				if err := flagSet.setFlag(flag.id, value); err != nil {
 					arg.err = err
 				}
 			}
+			if arg.err != nil && valueFile != "" {
+				// Do not expose the file content
+				arg.err = fmt.Errorf("failed to parse the content of file %s for argument %s%s", valueFile, arg.dash, arg.name)
+			}
 		}
 	}
 
@@ -148,7 +212,22 @@ func New(o Options) (*FlagSet, error) {
 		}
 
 		if flag.env != "" {
-			if ev, ok := os.LookupEnv(flag.env); ok {
+			ev, ok := flagSet.lookupEnv(flag.env)
+			evFile := ""
+			if !ok {
+				// Check the file variable (i.e. `PASSWORD_FILE=/run/secrets/password`)
+				if p, pok := flagSet.lookupEnv(flag.env + "_FILE"); pok {
+					evFile = p
+					v, err := readFileValue(evFile)
+					if err != nil {
+						flag.valueBy = "env"
+						flag.err = fmt.Errorf("failed to read file %s for %s_FILE due to %s", evFile, flag.env, err.Error())
+						continue
+					}
+					ev, ok = v, true
+				}
+			}
+			if ok {
 				flag.valueBy = "env"
 				if flag.delimiter != "" && strings.HasPrefix(flag.valueType, "[]") {
 					values := strings.Split(ev, flag.delimiter)
@@ -167,10 +246,38 @@ func New(o Options) (*FlagSet, error) {
 						flag.err = err
 					}
 				}
+				if flag.err != nil && evFile != "" {
+					// Do not expose the file content
+					flag.err = fmt.Errorf("failed to parse the content of file %s for %s_FILE", evFile, flag.env)
+				}
 				continue
 			}
 		}
 
+		if entry, ok := flagSet.configs[flag.id]; ok {
+			flag.valueBy = "config"
+			for _, value := range entry.values {
+				if flag.delimiter != "" && strings.HasPrefix(flag.valueType, "[]") && !entry.isArray {
+					values := strings.Split(value, flag.delimiter)
+					for _, v := range values {
+						// Ignore empty ones
+						v = strings.TrimSpace(v)
+						if v == "" {
+							continue
+						}
+						if err := flagSet.setFlag(flag.id, v); err != nil {
+							flag.err = fmt.Errorf("%s: %s", entry.position(), err.Error())
+						}
+					}
+				} else {
+					if err := flagSet.setFlag(flag.id, value); err != nil {
+						flag.err = fmt.Errorf("%s: %s", entry.position(), err.Error())
+					}
+				}
+			}
+			continue
+		}
+
 		if flag.valueDefault != "" {
 			flag.valueBy = "default"
 			if flag.delimiter != "" && strings.HasPrefix(flag.valueType, "[]") {
@@ -246,8 +353,12 @@ func New(o Options) (*FlagSet, error) {
 
 			// Check requirement when the flag is not present
 			if flag.required && flag.args == nil {
-				// Skip error when the value is set by default value or env variables
-				if flag.valueBy == "default" || flag.valueBy == "env" {
+				// Skip error when the value is set by default value, env variables or config files
+				if flag.valueBy == "default" || flag.valueBy == "env" || flag.valueBy == "config" {
+					continue
+				}
+				// Prompt for the value
+				if o.Prompt != nil && flagSet.promptFlag(flag, o.Prompt) {
 					continue
 				}
 				// Otherwise it's an error
@@ -270,20 +381,119 @@ func New(o Options) (*FlagSet, error) {
 		}
 	}
 
+	// Iterate over the arguments and add their sources to the errors (i.e. `args.txt:3: unknown argument: --foo`)
+	for _, arg := range flagSet.args {
+		if arg.err != nil && arg.source != "" {
+			arg.err = fmt.Errorf("%s: %s", arg.source, arg.err.Error())
+		}
+	}
+
+	// Validate the flags and the present commands
+	flagSet.validate()
+
 	return &flagSet, nil
 }
 
+// Validator is the interface that is implemented by the flag structs those validate themselves (i.e. cross-field rules)
+type Validator interface {
+	Validate() error
+}
+
+// validate calls the Validate methods of the flags struct and the present command structs those implement Validator
+// The errors of the commands are prefixed by their command names (i.e. `math pow: invalid base`).
+// The structs those have flag errors (i.e. `failed to parse 'abc' as float64`) are not validated since
+// their values might be zero or partial.
+func (flagSet *FlagSet) validate() {
+	// Init vars
+	rv := reflect.ValueOf(flagSet.flagsRaw)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return
+	}
+	scopes := flagSet.errorScopes()
+
+	// Flags struct
+	if v, ok := flagSet.flagsRaw.(Validator); ok && !scopes[-1] {
+		if err := v.Validate(); err != nil {
+			flagSet.errs = append(flagSet.errs, err)
+		}
+	}
+
+	// Iterate over the present commands
+	for _, flag := range flagSet.flags {
+		if flag.kind != "command" || flag.args == nil || scopes[flag.id] {
+			continue
+		}
+		fv := rv.Elem().FieldByIndex(flag.fieldIndex)
+		if v, ok := fv.Addr().Interface().(Validator); ok {
+			if err := v.Validate(); err != nil {
+				line := flag.command
+				for f := flagSet.flagByID(flag.parentID); f != nil; f = flagSet.flagByID(f.parentID) {
+					line = fmt.Sprintf("%s %s", f.command, line)
+				}
+				flagSet.errs = append(flagSet.errs, fmt.Errorf("%s: %w", line, err))
+			}
+		}
+	}
+}
+
+// errorScopes returns the command flag ids those have errors in their flags, arguments or subcommands
+// The flags struct (i.e. the root) is stored by -1 and it has errors if there is any error.
+func (flagSet *FlagSet) errorScopes() map[int]bool {
+	// Init vars
+	result := map[int]bool{}
+	add := func(id int) {
+		result[-1] = true
+		for f := flagSet.flagByID(id); f != nil; f = flagSet.flagByID(f.parentID) {
+			result[f.id] = true
+		}
+	}
+
+	// Iterate over the errors
+	for _, flag := range flagSet.flags {
+		if flag.err != nil {
+			add(flag.id)
+		}
+	}
+	for _, arg := range flagSet.args {
+		if arg == nil || arg.err == nil {
+			continue
+		}
+		if c := flagSet.commandByID(arg.commandID); c != nil {
+			add(c.flagID)
+		} else {
+			add(arg.flagID)
+		}
+	}
+	for _, command := range flagSet.commands {
+		if command != nil && command.err != nil {
+			add(command.flagID)
+		}
+	}
+	for _, setting := range flagSet.settings {
+		if setting != nil && setting.err != nil {
+			add(setting.parentID)
+		}
+	}
+
+	return result
+}
+
 // FlagSet represents a flag set
 type FlagSet struct {
 	flags          []*Flag
 	flagsRaw       interface{}
 	args           []*Arg
 	argsRaw        []string
+	argsSource     []string // by response files
+	tokenized      bool     // by Split
 	argsParsed     bool
 	commands       []*Command
 	commandsParsed bool
 	settings       []*Setting
 	settingsParsed bool
+	errs           []error              // by validators
+	configs        map[int]*configEntry // by flag id
+	envs           map[string]string    // by env files
 }
 
 // parseSettings parses the flags and update the settings
@@ -457,6 +667,36 @@ func (flagSet *FlagSet) FlagByName(name string) *Flag {
 	return result
 }
 
+// flagPath returns the path of the given flag (i.e. Foo.Bar)
+func (flagSet *FlagSet) flagPath(flag *Flag) string {
+	path := flag.name
+	for f := flagSet.flagByID(flag.parentID); f != nil; f = flagSet.flagByID(f.parentID) {
+		path = fmt.Sprintf("%s.%s", f.name, path)
+	}
+	return path
+}
+
+// bindEnv sets the environment variable names of the argument flags those have no env tag
+// by the given prefix and the flag paths (i.e. MYAPP_MATH_POW_BASE for Math.Pow.Base)
+// The words of the flag names are separated by underscore (i.e. MYAPP_MAX_RETRIES for MaxRetries).
+func (flagSet *FlagSet) bindEnv(prefix string) {
+	regEnv := regexp.MustCompile("[^A-Z0-9]+")
+	regWord := regexp.MustCompile("([a-z0-9])([A-Z])")
+	regAcronym := regexp.MustCompile("([A-Z]+)([A-Z][a-z])")
+	prefix = strings.Trim(regEnv.ReplaceAllString(strings.ToUpper(prefix), "_"), "_")
+	for _, flag := range flagSet.flags {
+		if flag.kind != "arg" || flag.env != "" || flag.envDisabled {
+			continue
+		}
+		name := regAcronym.ReplaceAllString(regWord.ReplaceAllString(flagSet.flagPath(flag), "${1}_${2}"), "${1}_${2}")
+		name = regEnv.ReplaceAllString(strings.ToUpper(name), "_")
+		if prefix != "" {
+			name = fmt.Sprintf("%s_%s", prefix, name)
+		}
+		flag.env = name
+	}
+}
+
 // FlagByArg returns a flag by the given argument name or returns nil if it doesn't exist
 // Nested flags are separated by dot (i.e. Foo.Bar)
 func (flagSet *FlagSet) FlagByArg(arg, command string) *Flag {
@@ -489,6 +729,7 @@ func (flagSet *FlagSet) FlagByArg(arg, command string) *Flag {
 // FlagArgs returns the flag arguments those exist in the argument list
 // If the flag is an argument then it return it's values (i.e. [foo bar] for `-f=foo -f=bar`)
 // If it's a command then it returns the command name and the rest of the arguments (i.e. [command -f=true --bar=baz qux] for `command -f --bar=baz qux`).
+// Values of the secret flags are masked (see SecretMask).
 // Nested flags are separated by dot (i.e. Foo.Bar)
 func (flagSet *FlagSet) FlagArgs(name string) []string {
 	if name == "" {
@@ -506,7 +747,11 @@ func (flagSet *FlagSet) FlagArgs(name string) []string {
 	// Iterate over the arguments
 	for _, v := range flag.args {
 		if flag.kind == "arg" {
-			result = append(result, v.value)
+			if flag.secret {
+				result = append(result, SecretMask)
+			} else {
+				result = append(result, v.value)
+			}
 		} else if flag.kind == "command" {
 			// Note that argument values ("argval") are coupled with their parent arguments hence
 			// they are not added into the flag arguments (see parseArgs method).
@@ -520,7 +765,11 @@ func (flagSet *FlagSet) FlagArgs(name string) []string {
 						arg = fmt.Sprintf("%s%s", arg, v.name)
 					}
 					if v.value != "" {
-						arg = fmt.Sprintf("%s=%s", arg, v.value)
+						if f := flagSet.flagByID(v.flagID); f != nil && f.secret {
+							arg = fmt.Sprintf("%s=%s", arg, SecretMask)
+						} else {
+							arg = fmt.Sprintf("%s=%s", arg, v.value)
+						}
 					}
 				} else {
 					// For example: command itself
@@ -562,6 +811,7 @@ func (flagSet *FlagSet) Errors() []error {
 			result = append(result, setting.err)
 		}
 	}
+	result = append(result, flagSet.errs...)
 	return result
 }
 
@@ -689,6 +939,12 @@ func (flagSet *FlagSet) parseCommands() {
 	flagSet.commandsParsed = true
 }
 
+// isTokenized returns whether the given argument is already unquoted by Split or not
+// The arguments of ParseString and the response files are not unquoted again.
+func (flagSet *FlagSet) isTokenized(arg *Arg) bool {
+	return flagSet.tokenized || arg.source != ""
+}
+
 // parseArgs parses the raw arguments and updates the arguments
 func (flagSet *FlagSet) parseArgs() {
 	if flagSet.argsParsed {
@@ -715,6 +971,9 @@ func (flagSet *FlagSet) parseArgs() {
 			indexFrom:  argIndex,
 			indexTo:    argIndex + 1,
 		}
+		if argIndex < len(flagSet.argsSource) {
+			newArg.source = flagSet.argsSource[argIndex]
+		}
 
 		// Check commands
 		for _, cmd := range flagSet.commands {
@@ -774,7 +1033,9 @@ func (flagSet *FlagSet) parseArgs() {
 			s := strings.SplitN(arg.name, "=", 2)
 			arg.name = s[0]
 			arg.value = strings.Join(s[1:], "")
-			if strings.HasPrefix(arg.value, "\"") {
+			if flagSet.isTokenized(arg) {
+				// already unquoted
+			} else if strings.HasPrefix(arg.value, "\"") {
 				arg.value = strings.Trim(arg.value, "\"")
 			} else if strings.HasPrefix(arg.value, "'") {
 				arg.value = strings.Trim(arg.value, "'")
@@ -786,7 +1047,9 @@ func (flagSet *FlagSet) parseArgs() {
 				if nextArg.kind == "arg" && !strings.HasPrefix(nextArg.arg, "-") {
 					arg.value = nextArg.arg
 					arg.indexTo = nextArg.indexTo
-					if strings.HasPrefix(arg.value, "\"") {
+					if flagSet.isTokenized(nextArg) {
+						// already unquoted
+					} else if strings.HasPrefix(arg.value, "\"") {
 						arg.value = strings.Trim(arg.value, "\"")
 					} else if strings.HasPrefix(arg.value, "'") {
 						arg.value = strings.Trim(arg.value, "'")
@@ -820,7 +1083,7 @@ func (flagSet *FlagSet) parseArgs() {
 							if arg.flagID == -1 {
 								if f := flagSet.FlagByArg(arg.name, ""); f != nil && f.global {
 									// Update the argument and it's flag
-									f.updatedBy = append(flag.updatedBy, "global argument")
+									f.updatedBy = append(f.updatedBy, "global argument")
 									f.args = append(f.args, arg)
 									arg.updatedBy = append(arg.updatedBy, "global argument")
 									arg.flagID = f.id
@@ -897,6 +1160,52 @@ func (flagSet *FlagSet) parseArgs() {
 	flagSet.argsParsed = true
 }
 
+// promptFlag reads the flag value by the given prompt function and sets it
+// It returns false when the prompt function stops prompting.
+func (flagSet *FlagSet) promptFlag(flag *Flag, prompt func(flag *Flag, err error) (string, bool)) bool {
+	var err error
+	for {
+		value, ok := prompt(flag, err)
+		if !ok {
+			return false
+		}
+
+		// Check the value
+		if value == "" && flag.nonempty {
+			err = fmt.Errorf("argument %s needs a value", flag.FormattedArg())
+			continue
+		}
+
+		// Update the flag value
+		err = nil
+		if strings.HasPrefix(flag.valueType, "[]") {
+			flagSet.unsetFlag(flag.id)
+		}
+		if flag.delimiter != "" && strings.HasPrefix(flag.valueType, "[]") {
+			values := strings.Split(value, flag.delimiter)
+			for _, v := range values {
+				// Ignore empty ones
+				v = strings.TrimSpace(v)
+				if v == "" {
+					continue
+				}
+				if e := flagSet.setFlag(flag.id, v); e != nil {
+					err = e
+				}
+			}
+		} else {
+			err = flagSet.setFlag(flag.id, value)
+		}
+		if err != nil {
+			flagSet.unsetFlag(flag.id)
+			continue
+		}
+
+		flag.valueBy = "prompt"
+		return true
+	}
+}
+
 // setFlag sets a flag value by the given flag id and value
 func (flagSet *FlagSet) setFlag(id int, value string) error {
 	if id < 0 {
@@ -914,11 +1223,17 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		return fmt.Errorf("flag %s can't be set", flag.name)
 	}
 
+	// Do not expose the secret values in the errors
+	valueErr := value
+	if flag.secret {
+		valueErr = SecretMask
+	}
+
 	// Set the value
 	switch flag.valueType {
 	case "bool":
 		if value != "true" && value != "false" {
-			return fmt.Errorf("failed to parse '%s' as bool", value)
+			return fmt.Errorf("failed to parse '%s' as bool", valueErr)
 		}
 		if value == "true" {
 			fv.SetBool(true)
@@ -931,7 +1246,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			v, err := strconv.ParseFloat(value, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as float64", value)
+				return fmt.Errorf("failed to parse '%s' as float64", valueErr)
 			}
 			fv.SetFloat(v)
 			flag.value = v
@@ -940,7 +1255,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			v, err := strconv.ParseInt(value, 10, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as int", value)
+				return fmt.Errorf("failed to parse '%s' as int", valueErr)
 			}
 			fv.SetInt(v)
 			flag.value = v
@@ -949,7 +1264,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			v, err := strconv.ParseInt(value, 10, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as int64", value)
+				return fmt.Errorf("failed to parse '%s' as int64", valueErr)
 			}
 			fv.SetInt(v)
 			flag.value = v
@@ -958,7 +1273,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			v, err := strconv.ParseUint(value, 10, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as uint", value)
+				return fmt.Errorf("failed to parse '%s' as uint", valueErr)
 			}
 			fv.SetUint(v)
 			flag.value = v
@@ -967,7 +1282,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			v, err := strconv.ParseUint(value, 10, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as uint64", value)
+				return fmt.Errorf("failed to parse '%s' as uint64", valueErr)
 			}
 			fv.SetUint(v)
 			flag.value = v
@@ -977,7 +1292,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		flag.value = value
 	case "[]bool":
 		if value != "true" && value != "false" {
-			return fmt.Errorf("failed to parse '%s' as bool", value)
+			return fmt.Errorf("failed to parse '%s' as bool", valueErr)
 		}
 		var b reflect.Value
 		if value == "true" {
@@ -992,7 +1307,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			f, err := strconv.ParseFloat(value, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as float64", value)
+				return fmt.Errorf("failed to parse '%s' as float64", valueErr)
 			}
 			v := reflect.Append(fv, reflect.ValueOf(f))
 			fv.Set(v)
@@ -1002,7 +1317,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			i, err := strconv.ParseInt(value, 10, 32)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as int", value)
+				return fmt.Errorf("failed to parse '%s' as int", valueErr)
 			}
 			v := reflect.Append(fv, reflect.ValueOf(int(i)))
 			fv.Set(v)
@@ -1012,7 +1327,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			i, err := strconv.ParseInt(value, 10, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as int64", value)
+				return fmt.Errorf("failed to parse '%s' as int64", valueErr)
 			}
 			v := reflect.Append(fv, reflect.ValueOf(i))
 			fv.Set(v)
@@ -1022,7 +1337,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			u, err := strconv.ParseUint(value, 10, 32)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as uint", value)
+				return fmt.Errorf("failed to parse '%s' as uint", valueErr)
 			}
 			v := reflect.Append(fv, reflect.ValueOf(uint(u)))
 			fv.Set(v)
@@ -1032,7 +1347,7 @@ func (flagSet *FlagSet) setFlag(id int, value string) error {
 		if value != "" {
 			u, err := strconv.ParseUint(value, 10, 64)
 			if err != nil {
-				return fmt.Errorf("failed to parse '%s' as uint64", value)
+				return fmt.Errorf("failed to parse '%s' as uint64", valueErr)
 			}
 			v := reflect.Append(fv, reflect.ValueOf(u))
 			fv.Set(v)
@@ -1131,6 +1446,21 @@ func (flagSet *FlagSet) unsetFlag(id int) error {
 	return nil
 }
 
+// readFileValue reads the given file and returns its content without the trailing newline
+func readFileValue(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		var pe *os.PathError
+		if errors.As(err, &pe) {
+			return "", pe.Err // the path is already known by the caller
+		}
+		return "", err
+	}
+	v := strings.TrimSuffix(string(b), "\n")
+	v = strings.TrimSuffix(v, "\r")
+	return v, nil
+}
+
 // structToFlags parses the given struct and return a list of flags
 func structToFlags(value interface{}) ([]*Flag, []error) {
 	// Init vars
@@ -1192,6 +1522,7 @@ func structFieldToFlag(sf structField) Flag {
 		global:          false,
 		delimiter:       sf.field.Tag.Get("delimiter"),
 		env:             strings.TrimSpace(sf.field.Tag.Get("env")),
+		timeout:         strings.TrimSpace(sf.field.Tag.Get("timeout")),
 		valueDefault:    strings.TrimSpace(sf.field.Tag.Get("default")),
 		valueType:       sf.field.Type.String(),
 		valueBy:         "",
@@ -1227,6 +1558,19 @@ func structFieldToFlag(sf structField) Flag {
 		flag.global = true
 	}
 
+	if sf.field.Tag.Get("secret") == "true" {
+		flag.secret = true
+	}
+
+	if sf.field.Tag.Get("file") == "true" {
+		flag.file = true
+	}
+
+	if flag.env == "-" {
+		flag.env = ""
+		flag.envDisabled = true
+	}
+
 	// Cleanup args
 	regArg, err := regexp.Compile("[^a-zA-Z0-9-_.]+")
 	if err == nil {
@@ -1238,7 +1582,7 @@ func structFieldToFlag(sf structField) Flag {
 	// Check the flag kind
 	if flag.short != "" || flag.long != "" {
 		flag.kind = "arg"
-	} else if flag.command != "" && strings.HasPrefix(flag.valueType, "struct") {
+	} else if flag.command != "" && sf.field.Type.Kind() == reflect.Struct {
 		flag.kind = "command"
 		flag.valueType = "struct"
 	} else if sf.field.Tag.Get("settings") == "true" {
@@ -1266,8 +1610,8 @@ func typeToStructField(value reflect.Type, parentIndex []int) []structField {
 		sf := structField{field: field, index: append(pi, field.Index...), parentIndex: parentIndex}
 		result = append(result, sf)
 
-		// Check nested fields
-		if strings.HasPrefix(field.Type.String(), "struct") {
+		// Check nested fields (anonymous structs or named command structs)
+		if field.Type.Kind() == reflect.Struct && (strings.HasPrefix(field.Type.String(), "struct") || field.Tag.Get("command") != "") {
 			result = append(result, typeToStructField(field.Type, sf.index)...)
 		}
 	}
@@ -1318,6 +1662,15 @@ func checkFlags(flags []*Flag) []error {
 			}
 		}
 
+		// Timeout
+		if v.timeout != "" {
+			if v.kind != "command" {
+				result = append(result, fmt.Errorf("timeout tag in %s field is only supported by commands", v.name))
+			} else if d, err := time.ParseDuration(v.timeout); err != nil || d <= 0 {
+				result = append(result, fmt.Errorf("invalid timeout %s in %s field", v.timeout, v.name))
+			}
+		}
+
 		// Type
 		ftFound := false
 		for _, vv := range supportedFlagTypes {
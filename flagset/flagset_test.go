@@ -2803,3 +2803,297 @@ func TestFlagSet_Errors(t *testing.T) {
 		So(flagErrors, ShouldContain, errors.New("failed to parse 'foo' as bool"))
 	})
 }
+
+func TestNew_envPrefix(t *testing.T) {
+	Convey("should bind the environment variables by the prefix", t, func() {
+		os.Setenv("MYAPP_MATH_POW_BASE", "2")
+		os.Setenv("MYAPP_VERBOSE", "true")
+		os.Setenv("MYAPP_DISABLED", "foo")
+		os.Setenv("EXPLICIT", "bar")
+		defer func() {
+			os.Unsetenv("MYAPP_MATH_POW_BASE")
+			os.Unsetenv("MYAPP_VERBOSE")
+			os.Unsetenv("MYAPP_DISABLED")
+			os.Unsetenv("EXPLICIT")
+		}()
+
+		flags := struct {
+			Verbose  bool   `short:"v"`
+			Disabled string `long:"disabled" env:"-"`
+			Explicit string `long:"explicit" env:"EXPLICIT"`
+			Math     struct {
+				MaxRetries int    `long:"max-retries"`
+				HTTPProxy  string `long:"http-proxy"`
+				Pow        struct {
+					Base     float64 `short:"b" long:"base"`
+					Exponent float64 `short:"e" long:"exponent" default:"1"`
+				} `command:"pow"`
+			} `command:"math"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "math", "pow"}, EnvPrefix: "myapp"})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flagSet.FlagByName("Verbose").Env(), ShouldEqual, "MYAPP_VERBOSE")
+		So(flagSet.FlagByName("Disabled").Env(), ShouldEqual, "")
+		So(flagSet.FlagByName("Explicit").Env(), ShouldEqual, "EXPLICIT")
+		So(flagSet.FlagByName("Math").Env(), ShouldEqual, "")
+		So(flagSet.FlagByName("Math.Pow.Base").Env(), ShouldEqual, "MYAPP_MATH_POW_BASE")
+		So(flagSet.FlagByName("Math.Pow.Exponent").Env(), ShouldEqual, "MYAPP_MATH_POW_EXPONENT")
+		So(flagSet.FlagByName("Math.MaxRetries").Env(), ShouldEqual, "MYAPP_MATH_MAX_RETRIES")
+		So(flagSet.FlagByName("Math.HTTPProxy").Env(), ShouldEqual, "MYAPP_MATH_HTTP_PROXY")
+		So(flags.Verbose, ShouldEqual, true)
+		So(flags.Disabled, ShouldEqual, "")
+		So(flags.Explicit, ShouldEqual, "bar")
+		So(flags.Math.Pow.Base, ShouldEqual, 2)
+		So(flags.Math.Pow.Exponent, ShouldEqual, 1)
+	})
+
+	Convey("should not bind the environment variables without a prefix", t, func() {
+		flags := struct {
+			Verbose bool `short:"v"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app"}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.FlagByName("Verbose").Env(), ShouldEqual, "")
+	})
+}
+
+func TestNew_files(t *testing.T) {
+	Convey("should read the flag values from the files", t, func() {
+		password := writeTestFile(t, "password", "s3cr3t\n")
+		token := writeTestFile(t, "token", "t0k3n\r\n")
+		ports := writeTestFile(t, "ports", "80,443")
+		os.Setenv("GOCMD_TEST_TOKEN_FILE", token)
+		os.Setenv("GOCMD_TEST_USER", "foo")
+		os.Setenv("GOCMD_TEST_USER_FILE", password) // variable overrides the file variable
+		defer func() {
+			os.Unsetenv("GOCMD_TEST_TOKEN_FILE")
+			os.Unsetenv("GOCMD_TEST_USER")
+			os.Unsetenv("GOCMD_TEST_USER_FILE")
+		}()
+
+		flags := struct {
+			Password string `long:"password" file:"true"`
+			Token    string `long:"token" env:"GOCMD_TEST_TOKEN" required:"true"`
+			User     string `long:"user" env:"GOCMD_TEST_USER"`
+			Ports    []int  `short:"p" file:"true" delimiter:","`
+			Literal  string `long:"literal"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "--password", "@" + password, "-p=@" + ports, "--literal=@" + password}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Password, ShouldEqual, "s3cr3t")
+		So(flags.Token, ShouldEqual, "t0k3n")
+		So(flags.User, ShouldEqual, "foo")
+		So(flags.Ports, ShouldResemble, []int{80, 443})
+		So(flags.Literal, ShouldEqual, "@"+password)
+	})
+
+	Convey("should return the file errors without exposing the content", t, func() {
+		secret := writeTestFile(t, "secret", "s3cr3t\n")
+		os.Setenv("GOCMD_TEST_PIN_FILE", secret)
+		os.Setenv("GOCMD_TEST_KEY_FILE", "/missing/key")
+		defer func() {
+			os.Unsetenv("GOCMD_TEST_PIN_FILE")
+			os.Unsetenv("GOCMD_TEST_KEY_FILE")
+		}()
+
+		flags := struct {
+			Number  int    `long:"number" file:"true"`
+			Missing string `long:"missing" file:"true"`
+			Pin     int    `long:"pin" env:"GOCMD_TEST_PIN"`
+			Key     string `long:"key" env:"GOCMD_TEST_KEY" required:"true"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "--number=@" + secret, "--missing", "@/missing/file"}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		flagErrors := flagSet.Errors()
+		So(flagErrors, ShouldHaveLength, 4)
+		So(flagErrors, ShouldContain, errors.New("failed to read file /missing/key for GOCMD_TEST_KEY_FILE due to no such file or directory"))
+		So(flagErrors, ShouldContain, errors.New("failed to parse the content of file "+secret+" for GOCMD_TEST_PIN_FILE"))
+		So(flagErrors, ShouldContain, errors.New("failed to parse the content of file "+secret+" for argument --number"))
+		So(flagErrors, ShouldContain, errors.New("failed to read file /missing/file for argument --missing due to no such file or directory"))
+		for _, err := range flagErrors {
+			So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+		}
+	})
+}
+
+func TestNew_secret(t *testing.T) {
+	Convey("should not expose the secret values", t, func() {
+		os.Setenv("GOCMD_TEST_PIN", "s3cr3t")
+		defer os.Unsetenv("GOCMD_TEST_PIN")
+
+		flags := struct {
+			Token  string `long:"token" secret:"true"`
+			Number int    `long:"number" secret:"true"`
+			Ports  []int  `long:"ports" secret:"true"`
+			Pin    int    `long:"pin" env:"GOCMD_TEST_PIN" secret:"true"`
+			Login  struct {
+				User     string `long:"user"`
+				Password string `long:"password" secret:"true"`
+			} `command:"login"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "--token", "t0k3n", "--number=s3cr3t", "--ports=s3cr3t", "login", "--user=foo", "--password", "p@ss"}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flags.Token, ShouldEqual, "t0k3n")
+		So(flags.Login.Password, ShouldEqual, "p@ss")
+		So(flagSet.FlagByName("Token").Value(), ShouldEqual, "t0k3n")
+		So(flagSet.FlagArgs("Token"), ShouldResemble, []string{flagset.SecretMask})
+		So(flagSet.FlagArgs("Login"), ShouldResemble, []string{"login", "--user=foo", "--password=" + flagset.SecretMask})
+
+		flagErrors := flagSet.Errors()
+		So(flagErrors, ShouldHaveLength, 3)
+		So(flagErrors, ShouldContain, errors.New("failed to parse '******' as int"))
+		for _, err := range flagErrors {
+			So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+		}
+	})
+}
+
+func TestNew_prompt(t *testing.T) {
+	Convey("should prompt for the missing required arguments", t, func() {
+		flags := struct {
+			Count  int      `long:"count" required:"true"`
+			Tags   []string `long:"tags" required:"true" delimiter:","`
+			Given  string   `long:"given" required:"true"`
+			Absent struct {
+				Foo string `long:"foo" required:"true"`
+			} `command:"absent"`
+		}{}
+		inputs := map[string][]string{
+			"Count": {"one", "1"},
+			"Tags":  {"a, b"},
+		}
+		var prompted []string
+		var promptErrs []error
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &flags,
+			Args:  []string{"./app", "--given=bar"},
+			Prompt: func(flag *flagset.Flag, err error) (string, bool) {
+				prompted = append(prompted, flag.Name())
+				promptErrs = append(promptErrs, err)
+				if len(inputs[flag.Name()]) == 0 {
+					return "", false
+				}
+				v := inputs[flag.Name()][0]
+				inputs[flag.Name()] = inputs[flag.Name()][1:]
+				return v, true
+			},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Count, ShouldEqual, 1)
+		So(flags.Tags, ShouldResemble, []string{"a", "b"})
+		So(flags.Given, ShouldEqual, "bar")
+		So(prompted, ShouldResemble, []string{"Count", "Count", "Tags"})
+		So(promptErrs, ShouldResemble, []error{nil, errors.New("failed to parse 'one' as int"), nil})
+		So(flagSet.FlagByName("Count").ValueBy(), ShouldEqual, "prompt")
+	})
+}
+
+type namedTestCommand struct {
+	Force bool `short:"f" long:"force"`
+	Sub   struct {
+		Level int `short:"l"`
+	} `command:"sub"`
+}
+
+func TestNew_namedCommands(t *testing.T) {
+	Convey("should parse the named struct commands", t, func() {
+		flags := struct {
+			Deploy  namedTestCommand `command:"deploy"`
+			Rollout namedTestCommand `command:"rollout"`
+			Other   namedTestCommand
+		}{}
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &flags,
+			Args:  []string{"./app", "deploy", "-f", "sub", "-l", "2"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Deploy.Force, ShouldBeTrue)
+		So(flags.Deploy.Sub.Level, ShouldEqual, 2)
+		So(flags.Rollout.Force, ShouldBeFalse)
+		So(flagSet.FlagByName("Deploy").Kind(), ShouldEqual, "command")
+		So(flagSet.FlagByName("Deploy").ValueType(), ShouldEqual, "struct")
+		So(flagSet.FlagArgs("Deploy.Sub"), ShouldResemble, []string{"sub", "-l=2"})
+		So(flagSet.FlagByName("Other"), ShouldBeNil)
+		So(flagSet.FlagByName("Other.Force"), ShouldBeNil)
+	})
+}
+
+type validatedTestFlags struct {
+	Min    int `long:"min"`
+	Max    int `long:"max"`
+	Deploy struct {
+		Rollout validatedTestCommand `command:"rollout"`
+	} `command:"deploy"`
+	Other validatedTestCommand `command:"other"`
+}
+
+func (f *validatedTestFlags) Validate() error {
+	if f.Min > f.Max {
+		return errors.New("min can't be greater than max")
+	}
+	return nil
+}
+
+type validatedTestCommand struct {
+	Replicas int `short:"r"`
+}
+
+func (c *validatedTestCommand) Validate() error {
+	if c.Replicas < 1 {
+		return errors.New("replicas must be positive")
+	}
+	return nil
+}
+
+func TestNew_validate(t *testing.T) {
+	Convey("should validate the flags and the present commands", t, func() {
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &validatedTestFlags{},
+			Args:  []string{"./app", "--min", "2", "--max", "1", "deploy", "rollout", "-r", "0"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldResemble, []error{
+			errors.New("min can't be greater than max"),
+			fmt.Errorf("deploy rollout: %w", errors.New("replicas must be positive")),
+		})
+
+		flagSet, err = flagset.New(flagset.Options{
+			Flags: &validatedTestFlags{},
+			Args:  []string{"./app", "--max", "1", "deploy", "rollout", "-r", "2"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+	})
+	Convey("should not validate the structs those have flag errors", t, func() {
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &validatedTestFlags{},
+			Args:  []string{"./app", "--min", "2", "--max", "1", "deploy", "rollout", "-r", "abc"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet.Errors(), ShouldResemble, []error{
+			errors.New("failed to parse 'abc' as int"),
+		})
+
+		flagSet, err = flagset.New(flagset.Options{
+			Flags: &validatedTestFlags{},
+			Args:  []string{"./app", "--min", "abc", "deploy", "rollout", "-r", "0"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet.Errors(), ShouldResemble, []error{
+			errors.New("failed to parse 'abc' as int"),
+			fmt.Errorf("deploy rollout: %w", errors.New("replicas must be positive")),
+		})
+	})
+}
@@ -0,0 +1,309 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	joinSafeRegexp = regexp.MustCompile(`^[a-zA-Z0-9_@%+=:,./-]+$`)
+)
+
+// Marshal returns the canonical argument list of the given flags (i.e. [deploy --replicas=3 --tag=a --tag=b])
+// Arguments those have default values are omitted and commands are included when they have an argument.
+// The result doesn't contain the program name and it can be parsed by New function.
+// Note that the values of the secret flags are not masked.
+func Marshal(flags interface{}) ([]string, error) {
+	// Check the flags
+	if flags == nil {
+		return nil, fmt.Errorf("flags are required")
+	} else if reflect.ValueOf(flags).Kind() != reflect.Ptr || reflect.Indirect(reflect.ValueOf(flags)).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("flags must be a struct pointer")
+	}
+
+	// Parse the flags
+	fs, errs := structToFlags(flags)
+	if errs != nil {
+		return nil, errs[0] // return the first error
+	}
+	flagSet := FlagSet{flags: fs, flagsRaw: flags}
+	return flagSet.marshal(-1, func(*Flag) bool { return false })
+}
+
+// Marshal returns the canonical argument list of the flag set by the current field values
+// Unlike Marshal function, the commands those are present in the arguments are always included
+// with their positional and unknown arguments (by their order, before the flags of the command).
+func (flagSet *FlagSet) Marshal() ([]string, error) {
+	return flagSet.marshal(-1, func(flag *Flag) bool { return flag.args != nil })
+}
+
+// marshal returns the canonical argument list of the flags by the given parent flag id
+func (flagSet *FlagSet) marshal(parentID int, present func(*Flag) bool) ([]string, error) {
+	// Init vars
+	result := []string{}
+	rValue := reflect.ValueOf(flagSet.flagsRaw).Elem()
+
+	// Positional and unknown arguments
+	raw, err := flagSet.marshalRaw(parentID)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, raw...)
+
+	// Arguments
+	for _, flag := range flagSet.flags {
+		if flag.kind != "arg" || flag.parentID != parentID {
+			continue
+		}
+		args, err := marshalFlag(flag, rValue.FieldByIndex(flag.fieldIndex))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, args...)
+	}
+
+	// Commands
+	for _, flag := range flagSet.flags {
+		if flag.kind != "command" || flag.parentID != parentID {
+			continue
+		}
+		args, err := flagSet.marshal(flag.id, present)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) > 0 || present(flag) {
+			result = append(result, flag.command)
+			result = append(result, args...)
+		}
+	}
+
+	return result, nil
+}
+
+// marshalRaw returns the positional and unknown arguments of the given command flag id by their order
+func (flagSet *FlagSet) marshalRaw(flagID int) ([]string, error) {
+	// Init vars
+	var result []string
+	commandID := -1
+	if flagID != -1 {
+		commandID = -2 // not present
+		for _, c := range flagSet.commands {
+			if c.flagID == flagID {
+				commandID = c.id
+				break
+			}
+		}
+	}
+	last := "" // unknown argument without a value
+
+	// Iterate over the arguments
+	for _, arg := range flagSet.args {
+		if arg.id == 0 || arg.kind != "arg" || arg.commandID != commandID {
+			continue
+		}
+		if arg.unnamed {
+			if last != "" {
+				return nil, fmt.Errorf("positional argument %s can't be expressed after unknown argument %s", arg.arg, last)
+			}
+			result = append(result, arg.arg)
+		} else if arg.flagID == -1 {
+			result = append(result, arg.arg)
+			last = ""
+			if arg.valueID != -1 && !arg.hasEq {
+				for _, a := range flagSet.args {
+					if a.id == arg.valueID {
+						result = append(result, a.arg)
+						break
+					}
+				}
+			} else if !arg.hasEq {
+				last = arg.arg
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// marshalFlag returns the arguments of the given argument flag and field value
+func marshalFlag(flag *Flag, fv reflect.Value) ([]string, error) {
+	// Init vars
+	name := fmt.Sprintf("--%s", flag.long)
+	if flag.long == "" {
+		name = fmt.Sprintf("-%s", flag.short)
+	}
+	itemType := strings.TrimPrefix(flag.valueType, "[]")
+
+	// Current values
+	var values []string
+	if strings.HasPrefix(flag.valueType, "[]") {
+		for i := 0; i < fv.Len(); i++ {
+			values = append(values, formatFlagValue(fv.Index(i)))
+		}
+	} else {
+		values = []string{formatFlagValue(fv)}
+	}
+
+	// Default values
+	var defaults []string
+	if flag.valueDefault != "" {
+		items := []string{flag.valueDefault}
+		if flag.delimiter != "" && strings.HasPrefix(flag.valueType, "[]") {
+			items = nil
+			for _, v := range strings.Split(flag.valueDefault, flag.delimiter) {
+				if v = strings.TrimSpace(v); v != "" {
+					items = append(items, v)
+				}
+			}
+		}
+		for _, v := range items {
+			v, err := normalizeFlagValue(itemType, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default value for %s field: %s", flag.name, err.Error())
+			}
+			defaults = append(defaults, v)
+		}
+	} else if !strings.HasPrefix(flag.valueType, "[]") {
+		defaults = []string{formatFlagValue(reflect.Zero(fv.Type()))}
+	}
+	if equalStrings(values, defaults) {
+		return nil, nil // default value
+	}
+
+	// Slices
+	if strings.HasPrefix(flag.valueType, "[]") {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("empty value of %s field can't be expressed as an argument", flag.name)
+		}
+		var result []string
+		for _, v := range values {
+			if flag.delimiter != "" && (v == "" || strings.TrimSpace(v) != v || strings.Contains(v, flag.delimiter)) {
+				return nil, fmt.Errorf("value of %s field can't be expressed with %s delimiter", flag.name, flag.delimiter)
+			}
+			a, err := marshalArg(flag, name, v)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, a)
+		}
+		if flag.delimiter != "" {
+			a, err := marshalArg(flag, name, strings.Join(values, flag.delimiter))
+			if err != nil {
+				return nil, err
+			}
+			return []string{a}, nil
+		}
+		return result, nil
+	}
+
+	// Bools
+	if flag.valueType == "bool" && values[0] == "true" {
+		return []string{name}, nil
+	}
+
+	a, err := marshalArg(flag, name, values[0])
+	if err != nil {
+		return nil, err
+	}
+	return []string{a}, nil
+}
+
+// marshalArg returns the argument by the given argument name and value (i.e. `--foo=bar`)
+// Values are quoted when it's necessary (see parseArgs method).
+func marshalArg(flag *Flag, name, value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("empty value of %s field can't be expressed as an argument", flag.name)
+	}
+	if strings.TrimSpace(value) != value || strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'") {
+		switch {
+		case !strings.HasPrefix(value, "\"") && !strings.HasSuffix(value, "\""):
+			value = fmt.Sprintf("\"%s\"", value)
+		case !strings.HasPrefix(value, "'") && !strings.HasSuffix(value, "'"):
+			value = fmt.Sprintf("'%s'", value)
+		default:
+			return "", fmt.Errorf("value of %s field can't be expressed as an argument", flag.name)
+		}
+	}
+	return fmt.Sprintf("%s=%s", name, value), nil
+}
+
+// formatFlagValue returns the string representation of the given field value
+func formatFlagValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.String:
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// normalizeFlagValue parses the given value by the given value type and returns its string representation
+func normalizeFlagValue(valueType, value string) (string, error) {
+	switch valueType {
+	case "bool":
+		v, err := strconv.ParseBool(value)
+		if err != nil || (value != "true" && value != "false") {
+			return "", fmt.Errorf("failed to parse '%s' as bool", value)
+		}
+		return strconv.FormatBool(v), nil
+	case "float64":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse '%s' as float64", value)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case "int", "int64":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse '%s' as %s", value, valueType)
+		}
+		return strconv.FormatInt(v, 10), nil
+	case "uint", "uint64":
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse '%s' as %s", value, valueType)
+		}
+		return strconv.FormatUint(v, 10), nil
+	}
+	return value, nil
+}
+
+// equalStrings returns whether the given string slices are equal or not
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Join joins the given arguments into a command line by quoting them when it's necessary
+// The result can be split by Split function (i.e. [a "b c"] for `a 'b c'`).
+func Join(args []string) string {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		if joinSafeRegexp.MatchString(arg) {
+			result[i] = arg
+		} else {
+			result[i] = fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", `'\''`))
+		}
+	}
+	return strings.Join(result, " ")
+}
@@ -0,0 +1,156 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type marshalTestFlags struct {
+	Verbose bool     `short:"v" long:"verbose"`
+	Color   bool     `long:"color" default:"true"`
+	Level   int      `short:"l"`
+	Ratio   float64  `long:"ratio" default:"1.5"`
+	Name    string   `long:"name" default:"app"`
+	Labels  []string `long:"label"`
+	Ports   []uint   `long:"ports" delimiter:","`
+	Echo    struct {
+		Settings bool `settings:"true" allow-unknown-arg:"true"`
+	} `command:"echo"`
+	Deploy struct {
+		Replicas int      `long:"replicas" default:"1"`
+		Tags     []string `long:"tag"`
+		Note     string   `long:"note"`
+		Rollback struct {
+			Force bool `short:"f"`
+		} `command:"rollback"`
+	} `command:"deploy"`
+}
+
+func TestMarshal(t *testing.T) {
+	Convey("should return the canonical arguments", t, func() {
+		flags := marshalTestFlags{}
+		flags.Color = true
+		flags.Ratio = 1.5
+		flags.Name = "app"
+		flags.Deploy.Replicas = 3
+		flags.Deploy.Tags = []string{"a", "b c"}
+		flags.Deploy.Note = " -x=\"y\" "
+		args, err := flagset.Marshal(&flags)
+		So(err, ShouldBeNil)
+		So(args, ShouldResemble, []string{"deploy", "--replicas=3", "--tag=a", "--tag=b c", `--note=" -x="y" "`})
+
+		flags = marshalTestFlags{}
+		flags.Verbose = true
+		flags.Level = -2
+		flags.Ratio = 0.25
+		flags.Name = "\"quoted\""
+		flags.Labels = []string{"x"}
+		flags.Ports = []uint{80, 443}
+		flags.Deploy.Replicas = 1
+		flags.Deploy.Rollback.Force = true
+		args, err = flagset.Marshal(&flags)
+		So(err, ShouldBeNil)
+		So(args, ShouldResemble, []string{"--verbose", "--color=false", "-l=-2", "--ratio=0.25", `--name='"quoted"'`, "--label=x", "--ports=80,443", "deploy", "rollback", "-f"})
+	})
+
+	Convey("should fail to return the canonical arguments", t, func() {
+		args, err := flagset.Marshal(nil)
+		So(err, ShouldBeError, errors.New("flags are required"))
+		So(args, ShouldBeNil)
+
+		args, err = flagset.Marshal(struct{}{})
+		So(err, ShouldBeError, errors.New("flags must be a struct pointer"))
+		So(args, ShouldBeNil)
+
+		args, err = flagset.Marshal(&struct {
+			Name string `long:"name" default:"app"`
+		}{})
+		So(err, ShouldBeError, errors.New("empty value of Name field can't be expressed as an argument"))
+		So(args, ShouldBeNil)
+
+		args, err = flagset.Marshal(&struct {
+			Tags []string `long:"tag" delimiter:","`
+		}{Tags: []string{"a,b"}})
+		So(err, ShouldBeError, errors.New("value of Tags field can't be expressed with , delimiter"))
+		So(args, ShouldBeNil)
+
+		args, err = flagset.Marshal(&struct {
+			Name string `long:"name"`
+		}{Name: `"a'`})
+		So(err, ShouldBeError, errors.New("value of Name field can't be expressed as an argument"))
+		So(args, ShouldBeNil)
+	})
+}
+
+func TestFlagSet_Marshal(t *testing.T) {
+	Convey("should round trip the arguments", t, func() {
+		tests := [][]string{
+			{"./app"},
+			{"./app", "echo"},
+			{"./app", "-v", "--color=false", "--ports", "80,443", "--label", "a", "--label=b"},
+			{"./app", "--name", "' x '", "deploy", "--tag", "a=b", "--tag=-c", "--replicas=2", "rollback", "-f"},
+			{"./app", "-l", "7", "--ratio=2", "deploy", "--note=\"single 'quoted'\""},
+		}
+		for _, test := range tests {
+			flags := marshalTestFlags{}
+			flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: test})
+			So(err, ShouldBeNil)
+			So(flagSet.Errors(), ShouldBeEmpty)
+			args, err := flagSet.Marshal()
+			So(err, ShouldBeNil)
+
+			flags2 := marshalTestFlags{}
+			flagSet2, err := flagset.New(flagset.Options{Flags: &flags2, Args: append([]string{"./app"}, args...)})
+			So(err, ShouldBeNil)
+			So(flagSet2.Errors(), ShouldBeEmpty)
+			So(flags2, ShouldResemble, flags)
+			args2, err := flagSet2.Marshal()
+			So(err, ShouldBeNil)
+			So(args2, ShouldResemble, args)
+
+			// Command line
+			if len(args) > 0 {
+				args3, err := flagset.Split(flagset.Join(args))
+				So(err, ShouldBeNil)
+				So(args3, ShouldResemble, args)
+			}
+		}
+	})
+
+	Convey("should include the present commands", t, func() {
+		flags := marshalTestFlags{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "echo"}})
+		So(err, ShouldBeNil)
+		args, err := flagSet.Marshal()
+		So(err, ShouldBeNil)
+		So(args, ShouldResemble, []string{"echo"})
+	})
+
+	Convey("should include the positional and unknown arguments", t, func() {
+		flags := marshalTestFlags{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "-v", "echo", "foo", "--bar", "baz", "--qux=1", "-x", "-l"}})
+		So(err, ShouldBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		args, err := flagSet.Marshal()
+		So(err, ShouldBeNil)
+		So(args, ShouldResemble, []string{"--verbose", "echo", "foo", "--bar", "baz", "--qux=1", "-x", "-l"})
+
+		flags2 := marshalTestFlags{}
+		flagSet2, err := flagset.New(flagset.Options{Flags: &flags2, Args: append([]string{"./app"}, args...)})
+		So(err, ShouldBeNil)
+		So(flagSet2.FlagArgs("Echo"), ShouldResemble, flagSet.FlagArgs("Echo"))
+	})
+}
+
+func TestJoin(t *testing.T) {
+	Convey("should join the arguments", t, func() {
+		So(flagset.Join(nil), ShouldEqual, "")
+		So(flagset.Join([]string{"deploy", "--replicas=3", "--tag=a b", "", "it's", "$HOME"}), ShouldEqual, `deploy --replicas=3 '--tag=a b' '' 'it'\''s' '$HOME'`)
+	})
+}
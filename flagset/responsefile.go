@@ -0,0 +1,116 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expandResponseFiles expands the response file arguments (i.e. `@args.txt`) in the raw arguments
+// The values of the file flags (i.e. `--password @/path/file`) and the escaped arguments (i.e. `@@foo`) are not expanded.
+func (flagSet *FlagSet) expandResponseFiles() error {
+	// Init vars
+	args := []string{}
+	sources := []string{}
+
+	// Iterate over the raw arguments
+	for i, arg := range flagSet.argsRaw {
+		if i == 0 || !strings.HasPrefix(arg, "@") || arg == "@" || flagSet.isFileFlagArg(flagSet.argsRaw[i-1]) {
+			args = append(args, arg)
+			sources = append(sources, "")
+			continue
+		}
+		if strings.HasPrefix(arg, "@@") {
+			args = append(args, arg[1:])
+			sources = append(sources, "")
+			continue
+		}
+		a, s, err := flagSet.readResponseFile(arg[1:], nil)
+		if err != nil {
+			return err
+		}
+		args = append(args, a...)
+		sources = append(sources, s...)
+	}
+	flagSet.argsRaw = args
+	flagSet.argsSource = sources
+
+	return nil
+}
+
+// readResponseFile reads the given response file and returns the arguments and their sources (i.e. `args.txt:3`)
+// Arguments are separated by whitespaces or new lines and they can be quoted by the shell rules.
+// Lines starting with `#` are comments. Nested response files are expanded recursively.
+func (flagSet *FlagSet) readResponseFile(path string, stack []string) ([]string, []string, error) {
+	// Check cycles
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	for _, v := range stack {
+		if v == abs {
+			return nil, nil, fmt.Errorf("response file %s includes itself", path)
+		}
+	}
+	stack = append(stack, abs)
+
+	// Read the file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response file due to %s", err.Error())
+	}
+	defer f.Close()
+
+	// Iterate over the lines
+	var args, sources []string
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		tokens, err := Split(scanner.Text())
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %s", path, lineNum, err.Error())
+		}
+		for _, token := range tokens {
+			if strings.HasPrefix(token, "@") && token != "@" && (len(args) == 0 || !flagSet.isFileFlagArg(args[len(args)-1])) {
+				if strings.HasPrefix(token, "@@") {
+					token = token[1:]
+				} else {
+					a, s, err := flagSet.readResponseFile(token[1:], stack)
+					if err != nil {
+						return nil, nil, fmt.Errorf("%s:%d: %s", path, lineNum, err.Error())
+					}
+					args = append(args, a...)
+					sources = append(sources, s...)
+					continue
+				}
+			}
+			args = append(args, token)
+			sources = append(sources, fmt.Sprintf("%s:%d", path, lineNum))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read response file due to %s", err.Error())
+	}
+
+	return args, sources, nil
+}
+
+// isFileFlagArg returns whether the given raw argument is a file flag that expects a value (i.e. `--password`)
+func (flagSet *FlagSet) isFileFlagArg(arg string) bool {
+	if !strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+		return false
+	}
+	name := strings.TrimLeft(arg, "-")
+	for _, flag := range flagSet.flags {
+		if flag.kind == "arg" && flag.file && (flag.short == name || flag.long == name) {
+			return true
+		}
+	}
+	return false
+}
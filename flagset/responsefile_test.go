@@ -0,0 +1,98 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNew_responseFiles(t *testing.T) {
+	Convey("should expand the response files", t, func() {
+		password := writeTestFile(t, "password", "s3cr3t")
+		nested := writeTestFile(t, "nested.txt", "--tag=c\n")
+		args := writeTestFile(t, "args.txt", `# Comment
+deploy
+--name "foo bar"  # inline comment
+--tag=a --tag 'b' @`+nested+`
+--note @@mention
+--password @`+password+`
+`)
+		flags := struct {
+			Verbose bool   `short:"v"`
+			Label   string `long:"label"`
+			Deploy  struct {
+				Name     string   `long:"name"`
+				Tags     []string `long:"tag"`
+				Note     string   `long:"note"`
+				Password string   `long:"password" file:"true"`
+				Replicas int      `long:"replicas"`
+			} `command:"deploy"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "-v", "--label", "@@top", "@" + args, "--replicas=3"}, ResponseFiles: true})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Verbose, ShouldEqual, true)
+		So(flags.Deploy.Name, ShouldEqual, "foo bar")
+		So(flags.Deploy.Tags, ShouldResemble, []string{"a", "b", "c"})
+		So(flags.Deploy.Note, ShouldEqual, "@mention")
+		So(flags.Deploy.Password, ShouldEqual, "s3cr3t")
+		So(flags.Deploy.Replicas, ShouldEqual, 3)
+		So(flags.Label, ShouldEqual, "@top")
+	})
+
+	Convey("should not expand the response files by default", t, func() {
+		flags := struct {
+			Name string `long:"name"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "--name", "@foo"}})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flags.Name, ShouldEqual, "@foo")
+	})
+
+	Convey("should return the argument errors with the file and line", t, func() {
+		args := writeTestFile(t, "args.txt", "--count=1\n\n--count=two --foo\n")
+		flags := struct {
+			Count int `long:"count"`
+		}{}
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "@" + args, "--bar"}, ResponseFiles: true})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		flagErrors := flagSet.Errors()
+		So(flagErrors, ShouldHaveLength, 3)
+		So(flagErrors, ShouldContain, errors.New(args+":3: failed to parse 'two' as int"))
+		So(flagErrors, ShouldContain, errors.New(args+":3: unknown argument: --foo"))
+		So(flagErrors, ShouldContain, errors.New("unknown argument: --bar"))
+	})
+
+	Convey("should fail to expand the response files", t, func() {
+		flags := struct {
+			Name string `long:"name"`
+		}{}
+
+		dir := t.TempDir()
+		a := filepath.Join(dir, "a.txt")
+		b := filepath.Join(dir, "b.txt")
+		writeTestFileAt(t, a, "--name=a\n@"+b)
+		writeTestFileAt(t, b, "@"+a)
+		flagSet, err := flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "@" + a}, ResponseFiles: true})
+		So(err, ShouldBeError, errors.New(a+":2: "+b+":1: response file "+a+" includes itself"))
+		So(flagSet, ShouldBeNil)
+
+		quote := writeTestFile(t, "quote.txt", "--name='foo")
+		flagSet, err = flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "@" + quote}, ResponseFiles: true})
+		So(err, ShouldBeError, errors.New(quote+":1: unterminated single quote"))
+		So(flagSet, ShouldBeNil)
+
+		flagSet, err = flagset.New(flagset.Options{Flags: &flags, Args: []string{"./app", "@/missing/args.txt"}, ResponseFiles: true})
+		So(err, ShouldBeError, errors.New("failed to read response file due to open /missing/args.txt: no such file or directory"))
+		So(flagSet, ShouldBeNil)
+	})
+}
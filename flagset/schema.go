@@ -0,0 +1,118 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+// Schema represents the machine-readable schema of a flag set
+type Schema struct {
+	AllowUnknownArg bool             `json:"allowUnknownArg,omitempty"`
+	Flags           []*SchemaFlag    `json:"flags,omitempty"`
+	Commands        []*SchemaCommand `json:"commands,omitempty"`
+}
+
+// SchemaCommand represents the schema of a command
+type SchemaCommand struct {
+	Name            string           `json:"name"`
+	Path            string           `json:"path"`
+	Command         string           `json:"command"`
+	Description     string           `json:"description,omitempty"`
+	Required        bool             `json:"required,omitempty"`
+	Nonempty        bool             `json:"nonempty,omitempty"`
+	AllowUnknownArg bool             `json:"allowUnknownArg,omitempty"`
+	Timeout         string           `json:"timeout,omitempty"`
+	Flags           []*SchemaFlag    `json:"flags,omitempty"`
+	Commands        []*SchemaCommand `json:"commands,omitempty"`
+}
+
+// SchemaFlag represents the schema of an argument flag
+// Default values of the secret flags are masked (see SecretMask).
+type SchemaFlag struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Short       string `json:"short,omitempty"`
+	Long        string `json:"long,omitempty"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Env         string `json:"env,omitempty"`
+	Delimiter   string `json:"delimiter,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Nonempty    bool   `json:"nonempty,omitempty"`
+	Global      bool   `json:"global,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`
+	File        bool   `json:"file,omitempty"`
+}
+
+// Schema returns the schema of the flag set (commands, arguments and their settings)
+func (flagSet *FlagSet) Schema() *Schema {
+	result := Schema{}
+	result.Flags, result.Commands = flagSet.schema(-1)
+	if s := flagSet.settingByParentID(-1); s != nil {
+		result.AllowUnknownArg = s.allowUnknownArg
+	}
+	return &result
+}
+
+// schema returns the schema of the flags and commands by the given parent flag id
+func (flagSet *FlagSet) schema(parentID int) ([]*SchemaFlag, []*SchemaCommand) {
+	// Init vars
+	var flags []*SchemaFlag
+	var commands []*SchemaCommand
+
+	// Iterate over the flags
+	for _, flag := range flagSet.flags {
+		if flag.parentID != parentID {
+			continue
+		}
+
+		if flag.kind == "arg" {
+			sf := SchemaFlag{
+				Name:        flag.name,
+				Path:        flagSet.flagPath(flag),
+				Short:       flag.short,
+				Long:        flag.long,
+				Type:        flag.valueType,
+				Default:     flag.valueDefault,
+				Env:         flag.env,
+				Delimiter:   flag.delimiter,
+				Description: flag.description,
+				Required:    flag.required,
+				Nonempty:    flag.nonempty,
+				Global:      flag.global,
+				Secret:      flag.secret,
+				File:        flag.file,
+			}
+			if flag.secret && sf.Default != "" {
+				sf.Default = SecretMask
+			}
+			flags = append(flags, &sf)
+		} else if flag.kind == "command" {
+			sc := SchemaCommand{
+				Name:        flag.name,
+				Path:        flagSet.flagPath(flag),
+				Command:     flag.command,
+				Description: flag.description,
+				Required:    flag.required,
+				Nonempty:    flag.nonempty,
+				Timeout:     flag.timeout,
+			}
+			if s := flagSet.settingByParentID(flag.id); s != nil {
+				sc.AllowUnknownArg = s.allowUnknownArg
+			}
+			sc.Flags, sc.Commands = flagSet.schema(flag.id)
+			commands = append(commands, &sc)
+		}
+	}
+
+	return flags, commands
+}
+
+// settingByParentID returns a setting by the given parent flag id or returns nil if it doesn't exist
+func (flagSet *FlagSet) settingByParentID(id int) *Setting {
+	for _, v := range flagSet.settings {
+		if v.parentID == id {
+			return v
+		}
+	}
+	return nil
+}
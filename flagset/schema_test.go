@@ -0,0 +1,61 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFlagSet_Schema(t *testing.T) {
+	Convey("should return the schema of the flag set", t, func() {
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &struct {
+				Verbose bool   `short:"v" long:"verbose" global:"true" description:"Verbose"`
+				Token   string `long:"token" default:"t0k3n" secret:"true" env:"TOKEN" description:"Token"`
+				Deploy  struct {
+					Settings bool     `settings:"true" allow-unknown-arg:"true"`
+					Tags     []string `short:"t" long:"tag" delimiter:"," required:"true" nonempty:"true"`
+					Key      string   `long:"key" file:"true"`
+					Rollback struct {
+						Force bool `short:"f"`
+					} `command:"rollback" description:"Rollback"`
+				} `command:"deploy" required:"true" description:"Deploy"`
+			}{},
+			Args: []string{"./app", "deploy", "-t", "a"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+
+		schema := flagSet.Schema()
+		So(schema, ShouldNotBeNil)
+		So(schema.AllowUnknownArg, ShouldBeFalse)
+		So(schema.Flags, ShouldHaveLength, 2)
+		So(schema.Flags[0], ShouldResemble, &flagset.SchemaFlag{Name: "Verbose", Path: "Verbose", Short: "v", Long: "verbose", Type: "bool", Description: "Verbose", Global: true})
+		So(schema.Flags[1], ShouldResemble, &flagset.SchemaFlag{Name: "Token", Path: "Token", Long: "token", Type: "string", Default: flagset.SecretMask, Env: "TOKEN", Description: "Token", Secret: true})
+		So(schema.Commands, ShouldHaveLength, 1)
+
+		deploy := schema.Commands[0]
+		So(deploy.Name, ShouldEqual, "Deploy")
+		So(deploy.Path, ShouldEqual, "Deploy")
+		So(deploy.Command, ShouldEqual, "deploy")
+		So(deploy.Description, ShouldEqual, "Deploy")
+		So(deploy.Required, ShouldBeTrue)
+		So(deploy.AllowUnknownArg, ShouldBeTrue)
+		So(deploy.Flags, ShouldHaveLength, 2)
+		So(deploy.Flags[0], ShouldResemble, &flagset.SchemaFlag{Name: "Tags", Path: "Deploy.Tags", Short: "t", Long: "tag", Type: "[]string", Delimiter: ",", Required: true, Nonempty: true})
+		So(deploy.Flags[1], ShouldResemble, &flagset.SchemaFlag{Name: "Key", Path: "Deploy.Key", Long: "key", Type: "string", File: true})
+		So(deploy.Commands, ShouldHaveLength, 1)
+		So(deploy.Commands[0].Path, ShouldEqual, "Deploy.Rollback")
+		So(deploy.Commands[0].Flags, ShouldResemble, []*flagset.SchemaFlag{{Name: "Force", Path: "Deploy.Rollback.Force", Short: "f", Type: "bool"}})
+		So(deploy.Commands[0].Commands, ShouldBeNil)
+
+		b, err := json.Marshal(deploy.Commands[0])
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"name":"Rollback","path":"Deploy.Rollback","command":"rollback","description":"Rollback","flags":[{"name":"Force","path":"Deploy.Rollback.Force","short":"f","type":"bool"}]}`)
+	})
+}
@@ -0,0 +1,102 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// ParseString returns a flag set by the given options and command line string
+// The command line is split by Split function and it should not contain the program name (i.e. `math pow -b 2`).
+// Quotes those are kept by Split (i.e. `'"quoted"'`) are kept in the values.
+func ParseString(o Options, s string) (*FlagSet, error) {
+	args, err := Split(s)
+	if err != nil {
+		return nil, err
+	}
+	name := ""
+	if len(os.Args) > 0 {
+		name = os.Args[0]
+	}
+	o.Args = append([]string{name}, args...)
+	o.tokenized = true
+	return New(o)
+}
+
+// Split splits the given command line into arguments by the POSIX shell rules (i.e. `a "b c" 'd'` for [a b c d])
+// It supports single quotes (literal), double quotes (`\"`, `\\`, `\$` and `\` escapes),
+// backslash escapes and comments (`#` at the beginning of a word). There is no expansion.
+func Split(s string) ([]string, error) {
+	// Init vars
+	var result []string
+	var sb strings.Builder
+	inWord := false
+
+	// Iterate over the characters
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				result = append(result, sb.String())
+				sb.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			// Comment until the end of the line
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '\\':
+			i++
+			if i == len(s) {
+				return nil, errors.New("unexpected end of string after backslash")
+			}
+			if s[i] != '\n' { // line continuation
+				sb.WriteByte(s[i])
+				inWord = true
+			}
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j == -1 {
+				return nil, errors.New("unterminated single quote")
+			}
+			sb.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inWord = true
+		case c == '"':
+			closed := false
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					closed = true
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) {
+					switch s[i+1] {
+					case '"', '\\', '$', '`':
+						i++
+					case '\n':
+						i++
+						continue // line continuation
+					}
+				}
+				sb.WriteByte(s[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		default:
+			sb.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		result = append(result, sb.String())
+	}
+
+	return result, nil
+}
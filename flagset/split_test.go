@@ -0,0 +1,98 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseString(t *testing.T) {
+	Convey("should parse the command line string", t, func() {
+		flags := struct {
+			Verbose bool `short:"v"`
+			Echo    struct {
+				Message  string   `short:"m" long:"message"`
+				Tags     []string `long:"tag"`
+				Settings bool     `settings:"true" allow-unknown-arg:"true"`
+			} `command:"echo"`
+		}{}
+		flagSet, err := flagset.ParseString(flagset.Options{Flags: &flags}, `-v echo --message "hello \"world\"" --tag='a b' --tag=c\ d rest # comment`)
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.Verbose, ShouldEqual, true)
+		So(flags.Echo.Message, ShouldEqual, `hello "world"`)
+		So(flags.Echo.Tags, ShouldResemble, []string{"a b", "c d"})
+		So(flagSet.FlagArgs("Echo"), ShouldResemble, []string{"echo", `--message=hello "world"`, "--tag=a b", "--tag=c d", "rest"})
+	})
+
+	Convey("should keep the inner quotes of the values", t, func() {
+		flags := struct {
+			M string `short:"m"`
+			N string `long:"n"`
+		}{}
+		flagSet, err := flagset.ParseString(flagset.Options{Flags: &flags}, `-m '"quoted"' --n="'x'"`)
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+		So(flagSet.Errors(), ShouldBeEmpty)
+		So(flags.M, ShouldEqual, `"quoted"`)
+		So(flags.N, ShouldEqual, `'x'`)
+	})
+
+	Convey("should fail to parse the command line string", t, func() {
+		flags := struct {
+			Message string `short:"m"`
+		}{}
+		flagSet, err := flagset.ParseString(flagset.Options{Flags: &flags}, `-m "foo`)
+		So(err, ShouldBeError, errors.New("unterminated double quote"))
+		So(flagSet, ShouldBeNil)
+	})
+}
+
+func TestSplit(t *testing.T) {
+	Convey("should split the command line", t, func() {
+		tests := []struct {
+			in  string
+			out []string
+		}{
+			{in: "", out: nil},
+			{in: "   ", out: nil},
+			{in: "a b\tc\nd", out: []string{"a", "b", "c", "d"}},
+			{in: `a "b c" 'd e'`, out: []string{"a", "b c", "d e"}},
+			{in: `"" ''`, out: []string{"", ""}},
+			{in: `--foo="bar baz"`, out: []string{"--foo=bar baz"}},
+			{in: `a\ b \"c\" \\`, out: []string{"a b", `"c"`, `\`}},
+			{in: `"a \"b\" \$c \d"`, out: []string{`a "b" $c \d`}},
+			{in: `'a \"b\" $c'`, out: []string{`a \"b\" $c`}},
+			{in: `$HOME ~ *`, out: []string{"$HOME", "~", "*"}},
+			{in: "a #comment\nb", out: []string{"a", "b"}},
+			{in: "a#b", out: []string{"a#b"}},
+			{in: "a \\\nb", out: []string{"a", "b"}},
+			{in: `a"b"'c'd`, out: []string{"abcd"}},
+		}
+		for _, v := range tests {
+			args, err := flagset.Split(v.in)
+			So(err, ShouldBeNil)
+			So(args, ShouldResemble, v.out)
+		}
+	})
+
+	Convey("should fail to split the command line", t, func() {
+		args, err := flagset.Split(`a 'b`)
+		So(err, ShouldBeError, errors.New("unterminated single quote"))
+		So(args, ShouldBeNil)
+
+		args, err = flagset.Split(`a "b`)
+		So(err, ShouldBeError, errors.New("unterminated double quote"))
+		So(args, ShouldBeNil)
+
+		args, err = flagset.Split(`a \`)
+		So(err, ShouldBeError, errors.New("unexpected end of string after backslash"))
+		So(args, ShouldBeNil)
+	})
+}
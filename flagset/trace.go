@@ -0,0 +1,108 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TraceEntry represents the parsing decisions of a raw argument
+type TraceEntry struct {
+	// Index is the index of the raw argument
+	Index int `json:"index"`
+	// Token is the raw argument. Values of the secret flags are masked (see SecretMask).
+	Token string `json:"token"`
+	// Kind is the classification of the argument (program, command, flag, value, positional or unknown)
+	Kind string `json:"kind"`
+	// Command is the path of the command that owns the argument (i.e. Math.Pow)
+	Command string `json:"command,omitempty"`
+	// Flag is the path of the matched flag (i.e. Math.Pow.Base)
+	Flag string `json:"flag,omitempty"`
+	// ValueBy is the final value source of the matched flag (arg, env, config, default or prompt)
+	ValueBy string `json:"valueBy,omitempty"`
+	// Source is the response file and line of the argument (i.e. `args.txt:3`)
+	Source string `json:"source,omitempty"`
+	// Steps are the internal decisions those are made for the argument (i.e. `in command range`)
+	Steps []string `json:"steps,omitempty"`
+	// Error is the argument error
+	Error string `json:"error,omitempty"`
+}
+
+// Trace returns the parsing decisions of the raw arguments by their order
+func (flagSet *FlagSet) Trace() []*TraceEntry {
+	// Init vars
+	result := []*TraceEntry{}
+
+	// Iterate over the arguments
+	for _, arg := range flagSet.args {
+		te := TraceEntry{
+			Index:  arg.id,
+			Token:  arg.arg,
+			Source: arg.source,
+			Steps:  append([]string(nil), arg.updatedBy...),
+		}
+		if arg.err != nil {
+			te.Error = arg.err.Error()
+		}
+
+		// Find the flag (argument values belong to the flag of their parent argument)
+		flagArg := arg
+		if arg.kind == "argval" {
+			for _, a := range flagSet.args {
+				if a.id == arg.parentID {
+					flagArg = a
+					break
+				}
+			}
+		}
+		flag := flagSet.flagByID(flagArg.flagID)
+
+		// Classify the argument
+		switch {
+		case arg.id == 0:
+			te.Kind = "program"
+		case arg.kind == "command":
+			te.Kind = "command"
+		case arg.kind == "argval":
+			te.Kind = "value"
+		case arg.unnamed:
+			te.Kind = "positional"
+		case flag != nil:
+			te.Kind = "flag"
+		default:
+			te.Kind = "unknown"
+		}
+
+		// Owning command
+		if c := flagSet.commandByID(flagArg.commandID); c != nil {
+			if f := flagSet.flagByID(c.flagID); f != nil {
+				te.Command = flagSet.flagPath(f)
+			}
+			if arg.kind == "command" {
+				te.Steps = append(te.Steps, c.updatedBy...)
+				if c.err != nil && te.Error == "" {
+					te.Error = c.err.Error()
+				}
+			}
+		}
+
+		// Matched flag
+		if flag != nil && te.Kind != "command" {
+			te.Flag = flagSet.flagPath(flag)
+			te.ValueBy = flag.valueBy
+			if flag.secret {
+				if arg.kind == "argval" {
+					te.Token = SecretMask
+				} else if i := strings.Index(arg.arg, "="); i > -1 {
+					te.Token = fmt.Sprintf("%s=%s", arg.arg[:i], SecretMask)
+				}
+			}
+		}
+
+		result = append(result, &te)
+	}
+
+	return result
+}
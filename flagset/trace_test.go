@@ -0,0 +1,66 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package flagset_test
+
+import (
+	"testing"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFlagSet_Trace(t *testing.T) {
+	Convey("should return the trace of the arguments", t, func() {
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &struct {
+				Verbose bool   `short:"v" global:"true"`
+				Token   string `long:"token" secret:"true"`
+				Math    struct {
+					Pow struct {
+						Base float64 `short:"b"`
+					} `command:"pow"`
+				} `command:"math"`
+			}{},
+			Args: []string{"./app", "--token", "t0k3n", "math", "pow", "-b", "x", "-v", "--nope"},
+		})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+
+		trace := flagSet.Trace()
+		So(trace, ShouldHaveLength, 9)
+		So(trace[0], ShouldResemble, &flagset.TraceEntry{Index: 0, Token: "./app", Kind: "program"})
+		So(trace[1], ShouldResemble, &flagset.TraceEntry{Index: 1, Token: "--token", Kind: "flag", Flag: "Token", ValueBy: "arg", Steps: []string{"top level arg"}})
+		So(trace[2], ShouldResemble, &flagset.TraceEntry{Index: 2, Token: flagset.SecretMask, Kind: "value", Flag: "Token", ValueBy: "arg"})
+		So(trace[3].Kind, ShouldEqual, "command")
+		So(trace[3].Command, ShouldEqual, "Math")
+		So(trace[3].Steps, ShouldContain, "found in the arguments")
+		So(trace[4].Kind, ShouldEqual, "command")
+		So(trace[4].Command, ShouldEqual, "Math.Pow")
+		So(trace[5], ShouldResemble, &flagset.TraceEntry{Index: 5, Token: "-b", Kind: "flag", Command: "Math.Pow", Flag: "Math.Pow.Base", ValueBy: "arg", Steps: []string{"in command range", "matched flag"}, Error: "failed to parse 'x' as float64"})
+		So(trace[6], ShouldResemble, &flagset.TraceEntry{Index: 6, Token: "x", Kind: "value", Command: "Math.Pow", Flag: "Math.Pow.Base", ValueBy: "arg", Steps: []string{"in command range"}})
+		So(trace[7], ShouldResemble, &flagset.TraceEntry{Index: 7, Token: "-v", Kind: "flag", Flag: "Verbose", ValueBy: "arg", Steps: []string{"in command range", "global argument"}})
+		So(trace[8], ShouldResemble, &flagset.TraceEntry{Index: 8, Token: "--nope", Kind: "unknown", Command: "Math.Pow", Steps: []string{"in command range"}, Error: "unknown argument: --nope"})
+	})
+
+	Convey("should return the trace with the sources and positional arguments", t, func() {
+		path := writeTestFile(t, "args.txt", "--password=p@ss\n")
+		flagSet, err := flagset.New(flagset.Options{
+			Flags: &struct {
+				Password string `long:"password" secret:"true"`
+				Echo     struct {
+					Settings bool `settings:"true" allow-unknown-arg:"true"`
+				} `command:"echo"`
+			}{},
+			Args:          []string{"./app", "@" + path, "echo", "hello"},
+			ResponseFiles: true,
+		})
+		So(err, ShouldBeNil)
+		So(flagSet, ShouldNotBeNil)
+
+		trace := flagSet.Trace()
+		So(trace, ShouldHaveLength, 4)
+		So(trace[1], ShouldResemble, &flagset.TraceEntry{Index: 1, Token: "--password=" + flagset.SecretMask, Kind: "flag", Flag: "Password", ValueBy: "arg", Source: path + ":1", Steps: []string{"top level arg"}})
+		So(trace[3], ShouldResemble, &flagset.TraceEntry{Index: 3, Token: "hello", Kind: "positional", Command: "Echo", Steps: []string{"in command range"}})
+	})
+}
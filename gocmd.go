@@ -5,22 +5,33 @@
 package gocmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/devfacet/gocmd/v3/flagset"
 	"github.com/devfacet/gocmd/v3/table"
+	"github.com/devfacet/gocmd/v3/template"
 )
 
 var (
-	flagHandlers []*FlagHandler
+	flagHandlers   []*FlagHandler
+	flagHandlersMu sync.Mutex
 )
 
+// TraceEnv is the environment variable that enables the parse trace dump (i.e. `GOCMD_TRACE=1 app ...`)
+// The trace (see Cmd.Trace) is printed to the standard error.
+const TraceEnv = "GOCMD_TRACE"
+
 // Options represents the options that can be set when creating a new command
 type Options struct {
 	// Name is the command name
@@ -31,22 +42,132 @@ type Options struct {
 	Description string
 	// Flags hold user defined command line arguments and commands
 	Flags interface{}
-	// Logger represents the logger that is being used for printing errors
+	// Args hold the command line arguments those are parsed by New function. Default is os.Args
+	// The first argument is the program name (i.e. [app math sqrt -n=9]).
+	Args []string
+	// Handlers hold the flag handlers and hooks of the command (see NewFlagHandler and NewHook)
+	// They run after the globally registered handlers (see HandleFlag) with the same priority.
+	Handlers []*FlagHandler
+	// IgnoreGlobalHandlers ignores the globally registered handlers (see HandleFlag)
+	// It isolates the commands those are embedded in the same program.
+	IgnoreGlobalHandlers bool
+	// Middlewares wrap the execution of the run handlers (see Middleware)
+	Middlewares []Middleware
+	// ConfigFiles hold the configuration file paths (INI/TOML subset). See flagset.Options
+	ConfigFiles []string
+	// EnvFiles hold the dotenv file paths for the flags those have env tags. See flagset.Options
+	EnvFiles []string
+	// EnvPrefix enables the automatic environment variable binding by the given prefix. See flagset.Options
+	EnvPrefix string
+	// ResponseFiles enables the response file arguments (i.e. `@args.txt`). See flagset.Options
+	ResponseFiles bool
+	// Logger represents the logger that is being used for printing errors. Default writes to Stderr
 	Logger Logger
 	// ConfigType is the configuration type
 	ConfigType ConfigType
+	// HandleSignals cancels the handler context (see Cmd.Context) on SIGINT or SIGTERM
+	// and exits with 130 or 143 after the handlers return, the grace period ends or a second signal is received.
+	HandleSignals bool
+	// GracePeriod is the maximum duration for waiting the handlers after the first signal. Default is no limit
+	// The handlers those don't return in the grace period are abandoned (they keep running but they are not waited for).
+	GracePeriod time.Duration
+	// SkipHandlerValidation disables the handler name validation (see Cmd.ValidateHandlers)
+	// By default, New and Run fail when a handler of the command (i.e. Options.Handlers and Cmd.HandleFlag)
+	// has an unknown flag name (i.e. a typo like `Math.Sqr`). The global handlers (see HandleFlag) are
+	// not validated since they are shared by all the commands.
+	SkipHandlerValidation bool
+	// WarnMissingHandlers prints a warning for each leaf command that has no handler
+	WarnMissingHandlers bool
+	// DispatchMode is the handler dispatch mode. Default is DispatchAll
+	DispatchMode DispatchMode
 	// AnyError checks all the errors and returns the first one if any
 	AnyError bool
 	// AutoHelp prints the usage content when the help flags are detected
 	AutoHelp bool
 	// AutoVersion prints the version content when the version flags are detected
 	AutoVersion bool
+	// AutoHelpJSON prints the schema (see Cmd.Schema) as JSON when the help-json flag is detected
+	// It's not enabled by ConfigTypeAuto since the help-json flag may be used by the app.
+	AutoHelpJSON bool
+	// HelpCommand enables the built-in help command that prints the usage of the given command
+	// (i.e. `app help math pow`). It's disabled when there is a user defined help command.
+	HelpCommand bool
+	// UsageTemplate is the template of the usage content (see Usage). Default is DefaultUsageTemplate
+	UsageTemplate string
+	// UsageTemplates hold the usage templates of the commands by their paths (i.e. Math.Pow)
+	// The commands those have no template use UsageTemplate. Parse fails when a path is not a command.
+	UsageTemplates map[string]string
 	// ExitOnError prints the error and exits the program when there is an error
 	ExitOnError bool
+	// Prompt prompts for the missing required arguments instead of failing
+	// when the standard input is a terminal or it's set by the Stdin option.
+	Prompt bool
+	// Stdin is the standard input that is being used for prompting. Default is os.Stdin
+	Stdin io.Reader
+	// Stdout is the standard output that is being used for the usage, version and prompts. Default is os.Stdout
+	Stdout io.Writer
+	// Stderr is the standard error that is being used for the default logger and traces. Default is os.Stderr
+	Stderr io.Writer
+	// Exit is the function that is being used for exiting the program. Default is os.Exit
+	Exit func(code int)
 }
 
 // New returns a command by the given options
+// It parses the command line arguments, handles the auto flags, runs the flag handlers
+// and exits the program when it's necessary (see ConfigTypeAuto).
+// Use NewCmd, Cmd.Parse and Cmd.Run for embedding a command in another program.
 func New(o Options) (*Cmd, error) {
+	// Init the command
+	cmd, err := NewCmd(o)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the arguments
+	args := o.Args
+	if args == nil {
+		args = os.Args
+	}
+	if err := cmd.Parse(args); err != nil {
+		if cmd.options.ExitOnError {
+			cmd.exit(cmd.fail(err))
+		}
+		return nil, err
+	}
+
+	// Auto flags
+	if ok, err := cmd.autoFlags(); err != nil {
+		if cmd.options.ExitOnError {
+			cmd.exit(cmd.fail(err))
+		}
+		return nil, err
+	} else if ok {
+		cmd.exit(ExitCodeOK)
+		return cmd, nil
+	}
+
+	// Check the handlers
+	if err := cmd.checkHandlers(); err != nil {
+		if cmd.options.ExitOnError {
+			cmd.exit(cmd.fail(err))
+		}
+		return nil, err
+	}
+
+	// Run the handlers
+	if fh, err := cmd.dispatch(context.Background()); err != nil {
+		if fh == nil || fh.exitOnError {
+			cmd.exit(cmd.fail(err))
+		}
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// NewCmd returns a command by the given options without parsing the arguments
+// The arguments should be parsed by Parse method and the command should be run by Run method.
+func NewCmd(o Options) (*Cmd, error) {
 	// Init the command
 	cmd := Cmd{
 		name:        o.Name,
@@ -55,11 +176,56 @@ func New(o Options) (*Cmd, error) {
 		flags:       o.Flags,
 		flagSet:     &flagset.FlagSet{},
 		logger:      o.Logger,
+		stdin:       o.Stdin,
+		stdout:      o.Stdout,
+		stderr:      o.Stderr,
+		exitFunc:    o.Exit,
+	}
+
+	// Init the handlers
+	if !o.IgnoreGlobalHandlers {
+		flagHandlersMu.Lock()
+		cmd.handlers = append(cmd.handlers, flagHandlers...)
+		flagHandlersMu.Unlock()
+	}
+	for _, v := range o.Handlers {
+		if v != nil {
+			cmd.handlers = append(cmd.handlers, v)
+		}
+	}
+
+	for _, v := range o.Middlewares {
+		if v != nil {
+			cmd.middlewares = append(cmd.middlewares, v)
+		}
+	}
+
+	// Check the standard input and outputs
+	if cmd.stdin == nil {
+		cmd.stdin = os.Stdin
+		// Prompt only when the standard input is a terminal
+		if o.Prompt && !isTerminal(os.Stdin) {
+			o.Prompt = false
+		}
+	}
+	if cmd.stdout == nil {
+		cmd.stdout = os.Stdout
+	}
+	if cmd.stderr == nil {
+		cmd.stderr = os.Stderr
+	}
+	if cmd.exitFunc == nil {
+		cmd.exitFunc = os.Exit
 	}
 
 	// Check the logger
 	if cmd.logger == nil {
-		cmd.logger = log.New(os.Stdout, "", 0)
+		cmd.logger = log.New(cmd.stderr, "", 0)
+	}
+
+	// Init the usage templates
+	if err := cmd.initUsageTemplates(o); err != nil {
+		return nil, err
 	}
 
 	// Check the config type
@@ -70,100 +236,368 @@ func New(o Options) (*Cmd, error) {
 		o.AutoVersion = true
 		o.ExitOnError = true
 	}
+	cmd.options = o
+
+	return &cmd, nil
+}
+
+// Parse parses the given arguments (i.e. os.Args) and updates the flags
+// The first argument is the program name. It returns the first flag error as an ExitError
+// with ExitCodeUsage when AnyError or ExitOnError option is set.
+func (cmd *Cmd) Parse(args []string) error {
+	// Init vars
+	o := cmd.options
+	cmd.args = args
+	cmd.helpArgs = nil
+	cmd.parsed = false
 
 	// If there is no any flag then
 	if o.Flags == nil {
-		return &cmd, nil
+		cmd.parsed = true
+		return nil
+	}
+
+	// Parse only the program name for the help command (i.e. `app help math pow`)
+	if cmd.helpArgs = cmd.helpCommandArgs(args); cmd.helpArgs != nil {
+		args = args[:1]
+	}
+
+	// Do not prompt when the usage or version might be requested (i.e. `app math pow --help`)
+	if cmd.helpArgs != nil || cmd.autoFlagArg(args) {
+		o.Prompt = false
 	}
 
 	// Parse flags
-	var err error
-	cmd.flagSet, err = flagset.New(flagset.Options{Flags: o.Flags})
+	fo := flagset.Options{
+		Flags:         o.Flags,
+		Args:          args,
+		ConfigFiles:   o.ConfigFiles,
+		EnvFiles:      o.EnvFiles,
+		EnvPrefix:     o.EnvPrefix,
+		ResponseFiles: o.ResponseFiles,
+	}
+	if o.Prompt {
+		fo.Prompt = cmd.prompt()
+	}
+	flagSet, err := flagset.New(fo)
+	if flagSet != nil && os.Getenv(TraceEnv) != "" {
+		cmd.flagSet = flagSet
+		cmd.printTrace(cmd.stderr)
+	}
 	if err != nil {
-		if o.ExitOnError {
-			cmd.logger.Printf("%s\n", err)
-			cmd.exit(1)
-		}
-		return nil, err
-	} else if (o.AnyError || o.ExitOnError) && len(cmd.flagSet.Errors()) > 0 {
-		if o.ExitOnError {
-			cmd.logger.Printf("%s\n", cmd.flagSet.Errors()[0])
-			cmd.exit(1)
+		// Config, env and response file errors
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	}
+	cmd.flagSet = flagSet
+	if err := cmd.checkUsageTemplates(); err != nil {
+		return err
+	}
+	// Flag errors are ignored when the usage or version is requested (i.e. `app math pow --help` without the required flags)
+	if !cmd.autoFlag() && (o.AnyError || o.ExitOnError) && len(cmd.flagSet.Errors()) > 0 {
+		return &ExitError{Code: ExitCodeUsage, Err: cmd.flagSet.Errors()[0]}
+	}
+	cmd.parsed = true
+
+	return nil
+}
+
+// Run handles the auto flags (see Options), runs the flag handlers by the given context
+// and returns the exit code (see ExitCode). The errors are printed by the logger unless they are silent.
+// Handlers can access the context by Context method. When the handlers are abandoned by a signal
+// or a timeout (see Options.GracePeriod and Cmd.Timeout), Run returns without waiting for them
+// and they should stop using the command and the flags when the context is done.
+func (cmd *Cmd) Run(ctx context.Context) int {
+	if !cmd.parsed {
+		return cmd.fail(errors.New("arguments are not parsed"))
+	}
+
+	// Auto flags
+	if ok, err := cmd.autoFlags(); err != nil {
+		return cmd.fail(err)
+	} else if ok {
+		return ExitCodeOK
+	}
+
+	// Check the handlers
+	if err := cmd.checkHandlers(); err != nil {
+		return cmd.fail(err)
+	}
+
+	// Run the handlers
+	if _, err := cmd.dispatch(ctx); err != nil {
+		return cmd.fail(err)
+	}
+
+	return ExitCodeOK
+}
+
+// autoFlags prints the version, schema or usage by the auto flags and the help command (see Options)
+// It returns true when one of them is printed.
+func (cmd *Cmd) autoFlags() (bool, error) {
+	// Init vars
+	o := cmd.options
+	if o.Flags == nil {
+		return false, nil
+	}
+
+	// Help command
+	if cmd.helpArgs != nil {
+		if err := cmd.printHelp(cmd.helpArgs); err != nil {
+			return false, err
 		}
-		return nil, cmd.flagSet.Errors()[0]
+		return true, nil
 	}
 
 	// Auto version
 	if o.AutoVersion {
-		ver := false
-		if f := cmd.flagSet.FlagByArg("v", ""); f != nil {
-			if v, ok := f.Value().(bool); ok && v {
-				ver = true
+		if ver, verEx := cmd.versionFlag(); ver || verEx {
+			cmd.PrintVersion(verEx)
+			return true, nil
+		}
+	}
+
+	// Auto help JSON
+	if o.AutoHelpJSON && cmd.boolFlag(helpJSONFlagArgs...) {
+		if err := cmd.PrintSchema(); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// Auto help
+	if o.AutoHelp {
+		if len(cmd.args) == 1 || cmd.helpFlag() {
+			// Print the usage of the deepest command (i.e. `app math pow --help`)
+			usage, err := cmd.renderUsage(cmd.deepestCommand())
+			if err != nil {
+				return false, err
 			}
+			fmt.Fprintln(cmd.stdout, usage)
+			return true, nil
 		}
-		if !ver {
-			if f := cmd.flagSet.FlagByArg("version", ""); f != nil {
-				if v, ok := f.Value().(bool); ok && v {
-					ver = true
-				}
+	}
+
+	return false, nil
+}
+
+// Arguments of the auto flags (see Options)
+var (
+	helpFlagArgs     = []string{"h", "help"}
+	versionFlagArgs  = []string{"v", "version", "vv"}
+	helpJSONFlagArgs = []string{"help-json"}
+)
+
+// autoFlagArg returns whether one of the enabled auto flags is present in the given raw arguments or not
+// It's used before parsing the arguments (i.e. for disabling the prompts).
+func (cmd *Cmd) autoFlagArg(args []string) bool {
+	// Init vars
+	var names []string
+	if cmd.options.AutoHelp {
+		names = append(names, helpFlagArgs...)
+	}
+	if cmd.options.AutoVersion {
+		names = append(names, versionFlagArgs...)
+	}
+	if cmd.options.AutoHelpJSON {
+		names = append(names, helpJSONFlagArgs...)
+	}
+
+	// Iterate over the arguments
+	for i, arg := range args {
+		if i == 0 || !strings.HasPrefix(arg, "-") {
+			continue
+		} else if arg == "--" {
+			break
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		for _, v := range names {
+			if name == v {
+				return true
 			}
 		}
-		verEx := false
-		if f := cmd.flagSet.FlagByArg("vv", ""); f != nil {
+	}
+
+	return false
+}
+
+// autoFlag returns whether one of the enabled auto flags is set by the parsed arguments or not
+func (cmd *Cmd) autoFlag() bool {
+	if cmd.helpArgs != nil {
+		return true
+	}
+	if cmd.options.AutoHelp && cmd.helpFlag() {
+		return true
+	}
+	if ver, verEx := cmd.versionFlag(); cmd.options.AutoVersion && (ver || verEx) {
+		return true
+	}
+	return cmd.options.AutoHelpJSON && cmd.boolFlag(helpJSONFlagArgs...)
+}
+
+// helpFlag returns whether the top level help flags are detected or not
+// The help flags within a command scope are detected only when they are global (i.e. `app math pow --help`).
+func (cmd *Cmd) helpFlag() bool {
+	return cmd.boolFlag(helpFlagArgs...)
+}
+
+// versionFlag returns whether the version flags and the extended version flag are detected or not
+func (cmd *Cmd) versionFlag() (bool, bool) {
+	return cmd.boolFlag("v", "version"), cmd.boolFlag("vv")
+}
+
+// boolFlag returns whether one of the top level bool flags by the given arguments is true or not
+func (cmd *Cmd) boolFlag(args ...string) bool {
+	for _, arg := range args {
+		if f := cmd.flagSet.FlagByArg(arg, ""); f != nil {
 			if v, ok := f.Value().(bool); ok && v {
-				verEx = true
+				return true
 			}
 		}
+	}
+	return false
+}
 
-		if ver || verEx {
-			cmd.PrintVersion(verEx)
-			cmd.exit(0)
-		}
+// runHandlers runs the handlers of the flags those have arguments by the given context
+// It stops at the first error and returns it with its handler (nil for ErrNoHandler).
+// The order is persistent pre-run hooks, pre-run hooks, run handlers, post-run hooks and persistent post-run hooks.
+func (cmd *Cmd) runHandlers(ctx context.Context) (*FlagHandler, error) {
+	// Init vars
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	cmd.ctx = ctx
+	handlers := make([]*FlagHandler, len(cmd.handlers))
+	copy(handlers, cmd.handlers)
+	handlers = append(handlers, cmd.runnerHandlers()...)
+	sort.Stable(byFlagHandlerPriority(handlers))
+	var persistentPre, pre, run, post, persistentPost []*FlagHandler
 
-	// Auto help
-	if o.AutoHelp {
-		help := false
-		if len(os.Args) == 1 {
-			help = true
-		} else {
-			if f := cmd.flagSet.FlagByArg("h", ""); f != nil {
-				if v, ok := f.Value().(bool); ok && v {
-					help = true
-				}
+	// Iterate over the handlers and group them by their kinds
+	deepest := cmd.deepestCommand()
+	deepestPath := ""
+	if deepest != nil {
+		deepestPath = cmd.flagPath(deepest)
+	}
+	dispatched := map[string]bool{}
+	for _, v := range handlers {
+		if v.kind == HandlerKindRun && cmd.FlagArgs(v.name) != nil {
+			if cmd.options.DispatchMode != DispatchDeepest || v.name == deepestPath {
+				run = append(run, v)
+				dispatched[v.name] = true
 			}
-			if !help {
-				if f := cmd.flagSet.FlagByArg("help", ""); f != nil {
-					if v, ok := f.Value().(bool); ok && v {
-						help = true
-					}
-				}
+		}
+	}
+	for _, v := range handlers {
+		if v.name != "" && cmd.FlagArgs(v.name) == nil {
+			continue // app hooks (i.e. empty name) always run
+		}
+		switch v.kind {
+		case HandlerKindPersistentPreRun:
+			persistentPre = append(persistentPre, v)
+		case HandlerKindPersistentPostRun:
+			persistentPost = append(persistentPost, v)
+		case HandlerKindPreRun:
+			if v.name == "" || dispatched[v.name] {
+				pre = append(pre, v)
+			}
+		case HandlerKindPostRun:
+			if v.name == "" || dispatched[v.name] {
+				post = append(post, v)
 			}
 		}
+	}
 
-		if help {
-			cmd.PrintUsage()
-			cmd.exit(0)
-		}
+	// Hooks run from the app to the subcommands and vice versa
+	for _, group := range [][]*FlagHandler{persistentPre, pre} {
+		sort.SliceStable(group, func(i, j int) bool {
+			return handlerDepth(group[i].name) < handlerDepth(group[j].name)
+		})
+	}
+	for _, group := range [][]*FlagHandler{post, persistentPost} {
+		sort.SliceStable(group, func(i, j int) bool {
+			return handlerDepth(group[i].name) > handlerDepth(group[j].name)
+		})
 	}
 
-	// Check handlers
-	sort.Sort(byFlagHandlerPriority(flagHandlers))
-	for _, v := range flagHandlers {
-		args := cmd.FlagArgs(v.name)
-		if cmd.FlagArgs(v.name) != nil {
-			err := v.handler(&cmd, args)
-			if err != nil {
-				if v.exitOnError {
-					cmd.logger.Printf("%s\n", err)
-					cmd.exit(1)
+	// Check the handler of the deepest command
+	if cmd.options.DispatchMode == DispatchDeepest && deepest != nil && len(run) == 0 {
+		return nil, fmt.Errorf("%w %s", ErrNoHandler, cmd.commandLine(deepest))
+	}
+
+	// Iterate over the handlers and run them
+	for _, group := range [][]*FlagHandler{persistentPre, pre, run, post, persistentPost} {
+		for _, v := range group {
+			h := HandlerFunc(v.handler)
+			if v.kind == HandlerKindRun {
+				// The first middleware is the outermost one
+				for i := len(cmd.middlewares) - 1; i >= 0; i-- {
+					h = cmd.middlewares[i](v, h)
 				}
-				return nil, err
+			}
+			var args []string
+			if v.name != "" {
+				args = cmd.FlagArgs(v.name)
+			}
+			if err := h(cmd, args); err != nil {
+				return v, err
 			}
 		}
 	}
 
-	return &cmd, nil
+	return nil, nil
+}
+
+// handlerDepth returns the depth of the given handler name (i.e. -1 for app hooks, 1 for Math.Pow)
+func handlerDepth(name string) int {
+	if name == "" {
+		return -1
+	}
+	return strings.Count(name, ".")
+}
+
+// deepestCommand returns the most specific command flag those is present in the arguments or returns nil
+func (cmd *Cmd) deepestCommand() *flagset.Flag {
+	var result *flagset.Flag
+	for _, flag := range cmd.flagSet.Flags() {
+		if flag.Kind() != "command" || cmd.FlagArgs(cmd.flagPath(flag)) == nil {
+			continue
+		}
+		if result == nil || len(flag.FieldIndex()) > len(result.FieldIndex()) {
+			result = flag
+		}
+	}
+	return result
+}
+
+// flagPath returns the path of the given flag (i.e. Math.Pow)
+func (cmd *Cmd) flagPath(flag *flagset.Flag) string {
+	path := flag.Name()
+	for f := cmd.parentFlag(flag); f != nil; f = cmd.parentFlag(f) {
+		path = fmt.Sprintf("%s.%s", f.Name(), path)
+	}
+	return path
+}
+
+// commandLine returns the command names of the given command flag (i.e. `math pow`)
+func (cmd *Cmd) commandLine(flag *flagset.Flag) string {
+	line := flag.Command()
+	for f := cmd.parentFlag(flag); f != nil; f = cmd.parentFlag(f) {
+		line = fmt.Sprintf("%s %s", f.Command(), line)
+	}
+	return line
+}
+
+// parentFlag returns the parent flag of the given flag or returns nil if it doesn't exist
+func (cmd *Cmd) parentFlag(flag *flagset.Flag) *flagset.Flag {
+	if flag.ParentID() == -1 {
+		return nil
+	}
+	for _, f := range cmd.flagSet.Flags() {
+		if f.ID() == flag.ParentID() {
+			return f
+		}
+	}
+	return nil
 }
 
 // ConfigType represents a configuration type
@@ -176,14 +610,67 @@ const (
 	ConfigTypeAuto = iota + 1
 )
 
+// DispatchMode represents a handler dispatch mode
+type DispatchMode int
+
+const (
+	// DispatchAll runs the handlers of all the flags those have arguments (i.e. Math and Math.Pow)
+	DispatchAll DispatchMode = iota
+	// DispatchDeepest runs only the handlers of the most specific command (i.e. Math.Pow)
+	// Parent commands can use persistent hooks (see HandlerKind) and the handlers of the arguments are not run.
+	// It returns ErrNoHandler when the command has no handler.
+	DispatchDeepest
+)
+
+// HandlerKind represents a flag handler kind
+type HandlerKind int
+
+const (
+	// HandlerKindRun is the default handler kind that runs as the flag handler
+	HandlerKindRun HandlerKind = iota
+	// HandlerKindPersistentPreRun runs before the handlers when the flag is present (i.e. parent commands)
+	// Parent hooks run before the subcommand hooks.
+	HandlerKindPersistentPreRun
+	// HandlerKindPersistentPostRun runs after the handlers when the flag is present (i.e. parent commands)
+	// Subcommand hooks run before the parent hooks. They are skipped when a handler fails.
+	HandlerKindPersistentPostRun
+	// HandlerKindPreRun runs before the run handlers of the same flag (i.e. Math.Pow). It's not inherited by the subcommands.
+	HandlerKindPreRun
+	// HandlerKindPostRun runs after the run handlers of the same flag (i.e. Math.Pow). It's not inherited by the subcommands.
+	// It's skipped when a handler fails.
+	HandlerKindPostRun
+)
+
+// HandlerFunc represents a flag handler function
+type HandlerFunc func(cmd *Cmd, args []string) error
+
+// Middleware represents a function that wraps the execution of a run handler (i.e. logging, timing)
+// The given flag handler is the one that is being wrapped.
+type Middleware func(fh *FlagHandler, next HandlerFunc) HandlerFunc
+
+// ErrNoHandler is the error that is returned when the command has no handler (see DispatchDeepest)
+var ErrNoHandler = errors.New("no handler for command")
+
 // Cmd represents a command
 type Cmd struct {
-	name        string
-	version     string
-	description string
-	flags       interface{}
-	flagSet     *flagset.FlagSet
-	logger      Logger
+	name           string
+	version        string
+	description    string
+	flags          interface{}
+	flagSet        *flagset.FlagSet
+	options        Options
+	args           []string
+	parsed         bool
+	helpArgs       []string
+	usageTemplates map[string]*template.Template
+	ctx            context.Context
+	handlers       []*FlagHandler
+	middlewares    []Middleware
+	logger         Logger
+	stdin          io.Reader
+	stdout         io.Writer
+	stderr         io.Writer
+	exitFunc       func(code int)
 }
 
 // Name returns the name of the command
@@ -201,6 +688,56 @@ func (cmd *Cmd) Description() string {
 	return cmd.description
 }
 
+// Stdout returns the standard output of the command (see Options.Stdout)
+func (cmd *Cmd) Stdout() io.Writer {
+	return cmd.stdout
+}
+
+// Stderr returns the standard error of the command (see Options.Stderr)
+func (cmd *Cmd) Stderr() io.Writer {
+	return cmd.stderr
+}
+
+// Context returns the context of the running command (see Run method)
+func (cmd *Cmd) Context() context.Context {
+	if cmd.ctx == nil {
+		return context.Background()
+	}
+	return cmd.ctx
+}
+
+// HandleFlag registers the flag handler for the given flag name on the command
+// It's used by Run method so it should be called before running the command.
+func (cmd *Cmd) HandleFlag(name string, handler func(cmd *Cmd, args []string) error) (*FlagHandler, error) {
+	fh, err := NewFlagHandler(name, handler)
+	if err != nil {
+		return nil, err
+	}
+	cmd.handlers = append(cmd.handlers, fh)
+
+	return fh, nil
+}
+
+// AddHook registers the hook for the given flag name and handler kind on the command (see NewHook)
+func (cmd *Cmd) AddHook(name string, kind HandlerKind, handler func(cmd *Cmd, args []string) error) (*FlagHandler, error) {
+	fh, err := NewHook(name, kind, handler)
+	if err != nil {
+		return nil, err
+	}
+	cmd.handlers = append(cmd.handlers, fh)
+
+	return fh, nil
+}
+
+// Use adds the given middlewares those wrap the execution of the run handlers (see Middleware)
+func (cmd *Cmd) Use(middlewares ...Middleware) {
+	for _, v := range middlewares {
+		if v != nil {
+			cmd.middlewares = append(cmd.middlewares, v)
+		}
+	}
+}
+
 // LookupFlag returns the flag arguments by the given flag name
 // Nested flags are separated by dot (i.e. Foo.Bar)
 func (cmd *Cmd) LookupFlag(name string) ([]string, bool) {
@@ -241,11 +778,6 @@ func (cmd *Cmd) PrintVersion(extra bool) {
 	version := ""
 	goVersion := runtime.Version()
 
-	// Update Go version for tests
-	if cmd.isTest() {
-		goVersion = "vTest"
-	}
-
 	// Set version
 	if extra {
 		version += fmt.Sprintf("App name    : %s\n", cmd.Name())
@@ -255,12 +787,55 @@ func (cmd *Cmd) PrintVersion(extra bool) {
 		version = strings.TrimPrefix(cmd.Version(), "v")
 	}
 
-	fmt.Println(version)
+	fmt.Fprintln(cmd.stdout, version)
 }
 
 // PrintUsage prints usage
 func (cmd *Cmd) PrintUsage() {
-	fmt.Println(cmd.usageContent())
+	fmt.Fprintln(cmd.stdout, cmd.usageContent())
+}
+
+// Schema represents the machine-readable schema of a command
+type Schema struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description,omitempty"`
+	*flagset.Schema
+}
+
+// Schema returns the schema of the command (name, version, description and the command tree)
+func (cmd *Cmd) Schema() *Schema {
+	return &Schema{
+		Name:        cmd.name,
+		Version:     cmd.version,
+		Description: cmd.description,
+		Schema:      cmd.flagSet.Schema(),
+	}
+}
+
+// PrintSchema prints the schema as JSON
+func (cmd *Cmd) PrintSchema() error {
+	b, err := json.MarshalIndent(cmd.Schema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.stdout, string(b))
+	return nil
+}
+
+// Trace returns the parsing decisions of the command line arguments
+func (cmd *Cmd) Trace() []*flagset.TraceEntry {
+	return cmd.flagSet.Trace()
+}
+
+// printTrace prints the parse trace to the given writer
+func (cmd *Cmd) printTrace(w io.Writer) {
+	t := table.New(table.Options{})
+	t.AddRow("INDEX", "TOKEN", "KIND", "COMMAND", "FLAG", "VALUE BY", "SOURCE", "STEPS", "ERROR")
+	for _, v := range cmd.Trace() {
+		t.AddRow(fmt.Sprint(v.Index), v.Token, v.Kind, v.Command, v.Flag, v.ValueBy, v.Source, strings.Join(v.Steps, ", "), v.Error)
+	}
+	fmt.Fprint(w, t.FormattedData())
 }
 
 // usageItem represents a usage item
@@ -324,7 +899,11 @@ func (cmd *Cmd) usageItems(kind string, parentID int, level int) []*usageItem {
 				right = fmt.Sprintf("%s (default", right)
 			}
 			if def {
-				right = fmt.Sprintf("%s %s", right, flag.ValueDefault())
+				if flag.Secret() {
+					right = fmt.Sprintf("%s %s", right, flagset.SecretMask)
+				} else {
+					right = fmt.Sprintf("%s %s", right, flag.ValueDefault())
+				}
 				if env {
 					right = fmt.Sprintf("%s - override $%s", right, flag.Env())
 				}
@@ -349,84 +928,18 @@ func (cmd *Cmd) usageItems(kind string, parentID int, level int) []*usageItem {
 }
 
 // usageContent parses the flags and return the usage content
+// The errors of the usage templates are printed by the logger.
 func (cmd *Cmd) usageContent() string {
-	// Init vars
-	hasOpt := false
-	hasCmd := false
-	usageItems := cmd.usageItems("", -1, 0)
-	for _, v := range usageItems {
-		if v.kind == "arg" {
-			hasOpt = true
-		} else if v.kind == "command" {
-			hasCmd = true
-		}
-		if hasOpt && hasCmd {
-			continue
-		}
-	}
-	t := table.New(table.Options{})
-
-	// Header and description
-	usage := "Usage: " + cmd.name
-	if hasOpt {
-		usage += " [options...]"
-	}
-	if hasCmd {
-		usage += " COMMAND [options...]"
-	}
-	usage += "\n\n"
-	if cmd.description != "" {
-		usage += cmd.description + "\n\n"
-	}
-
-	// Options
-	if hasOpt {
-		t.AddRow("Options:")
-		for _, v := range usageItems {
-			if v.kind == "arg" && v.parentID == -1 {
-				t.AddRow(fmt.Sprintf("%s%s ", strings.Repeat("  ", v.level), v.left), v.right)
-			}
-		}
-		t.AddRow(" ")
-	}
-
-	if hasCmd {
-		t.AddRow("Commands:")
-		l := len(usageItems)
-		for i := 0; i < l; i++ {
-			v := usageItems[i]
-			if v.kind == "command" || (v.kind == "arg" && v.parentID != -1) {
-				// Commands and their arguments are already sorted
-				t.AddRow(fmt.Sprintf("%s%s ", strings.Repeat("  ", v.level), v.left), v.right)
-			}
-		}
-	}
-
-	if len(t.Data()) > 0 {
-		usage += t.FormattedData()
+	usage, err := cmd.renderUsage(nil)
+	if err != nil {
+		cmd.logger.Printf("%s\n", err)
 	}
-
 	return usage
 }
 
-func (cmd *Cmd) isTest() bool {
-	if len(os.Args) > 0 {
-		if strings.Contains(os.Args[0], "gocmd.test") {
-			return true
-		}
-		for _, v := range os.Args {
-			if strings.HasPrefix(v, "-test.") {
-				return true
-			}
-		}
-	}
-	return false
-}
-
+// exit exits the program by the given exit code (see Options.Exit)
 func (cmd *Cmd) exit(code int) {
-	if !cmd.isTest() {
-		os.Exit(code)
-	}
+	cmd.exitFunc(code)
 }
 
 // FlagHandler represents a flag handler
@@ -441,6 +954,20 @@ type FlagHandler struct {
 	handler func(cmd *Cmd, args []string) error
 	// exitOnError prints the error and exits the program when the handler returns an error
 	exitOnError bool
+	// kind of the flag handler
+	kind HandlerKind
+	// global is whether the handler is registered by HandleFlag or not
+	global bool
+}
+
+// Name returns the flag name of the handler (i.e. empty for app hooks)
+func (fh *FlagHandler) Name() string {
+	return fh.name
+}
+
+// Kind returns the kind of the handler
+func (fh *FlagHandler) Kind() HandlerKind {
+	return fh.kind
 }
 
 // SetPriority sets the value of the priority
@@ -453,8 +980,18 @@ func (fh *FlagHandler) SetExitOnError(v bool) {
 	fh.exitOnError = v
 }
 
-// HandleFlag registers the flag handle for the given flag name
-func HandleFlag(name string, handler func(cmd *Cmd, args []string) error) (*FlagHandler, error) {
+// SetKind sets the value of the kind
+// App hooks (i.e. empty flag name) can't be run handlers so HandlerKindRun is ignored for them.
+func (fh *FlagHandler) SetKind(v HandlerKind) {
+	if fh.name == "" && v == HandlerKindRun {
+		return
+	}
+	fh.kind = v
+}
+
+// NewFlagHandler returns a flag handler for the given flag name
+// Unlike HandleFlag, it doesn't register the handler globally so it should be passed by Options.Handlers.
+func NewFlagHandler(name string, handler func(cmd *Cmd, args []string) error) (*FlagHandler, error) {
 	if name == "" {
 		return nil, errors.New("invalid flag name")
 	}
@@ -466,11 +1003,46 @@ func HandleFlag(name string, handler func(cmd *Cmd, args []string) error) (*Flag
 		handler:     handler,
 		exitOnError: true,
 	}
-	flagHandlers = append(flagHandlers, &fh)
 
 	return &fh, nil
 }
 
+// NewHook returns a hook by the given flag name and handler kind
+// Hooks those have empty flag name are app hooks and they always run with nil arguments (see Cmd.Run).
+func NewHook(name string, kind HandlerKind, handler func(cmd *Cmd, args []string) error) (*FlagHandler, error) {
+	if kind == HandlerKindRun {
+		return nil, errors.New("invalid hook kind")
+	}
+
+	// Init vars
+	fh := FlagHandler{
+		name:        name,
+		priority:    0,
+		handler:     handler,
+		exitOnError: true,
+		kind:        kind,
+	}
+
+	return &fh, nil
+}
+
+// HandleFlag registers the flag handle for the given flag name
+// Registered handlers are shared by all the commands those are created afterwards.
+// Use NewFlagHandler and Options.Handlers for the command specific handlers
+// and Options.IgnoreGlobalHandlers for ignoring the registered ones.
+func HandleFlag(name string, handler func(cmd *Cmd, args []string) error) (*FlagHandler, error) {
+	fh, err := NewFlagHandler(name, handler)
+	if err != nil {
+		return nil, err
+	}
+	fh.global = true
+	flagHandlersMu.Lock()
+	flagHandlers = append(flagHandlers, fh)
+	flagHandlersMu.Unlock()
+
+	return fh, nil
+}
+
 // byFlagHandlerPriority implements sort.Interface for []*FlagHandler
 type byFlagHandlerPriority []*FlagHandler
 
@@ -4,7 +4,13 @@
 package gocmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
 	"os"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -91,6 +97,45 @@ func TestCmd_usageItems(t *testing.T) {
 		So(usageItems[6].right, ShouldEqual, "Test (default $HOME)")
 		So(usageItems[6].level, ShouldEqual, 3)
 	})
+
+	Convey("should return the usage items with the derived env names", t, func() {
+		cmd, err := New(Options{
+			Name: "test",
+			Flags: &struct {
+				Foo bool `short:"f" long:"foo" description:"Test foo"`
+				Bar struct {
+					Baz string `short:"b" long:"baz" default:"qux" description:"Test baz"`
+					Qux string `short:"q" long:"qux" env:"-" description:"Test qux"`
+				} `command:"bar" description:"Bar command"`
+			}{},
+			EnvPrefix: "TEST",
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+
+		usageItems := cmd.usageItems("", -1, 0)
+		So(usageItems, ShouldHaveLength, 4)
+		So(usageItems[0].right, ShouldEqual, "Test foo (default $TEST_FOO)")
+		So(usageItems[2].right, ShouldEqual, "Test baz (default qux - override $TEST_BAR_BAZ)")
+		So(usageItems[3].right, ShouldEqual, "Test qux")
+	})
+
+	Convey("should return the usage items with the masked secret defaults", t, func() {
+		cmd, err := New(Options{
+			Name: "test",
+			Flags: &struct {
+				Token    string `short:"t" long:"token" default:"t0k3n" secret:"true" description:"Token"`
+				Password string `short:"p" long:"password" default:"p@ss" env:"PASSWORD" secret:"true" description:"Password"`
+			}{},
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+
+		usageItems := cmd.usageItems("", -1, 0)
+		So(usageItems, ShouldHaveLength, 2)
+		So(usageItems[0].right, ShouldEqual, "Token (default ******)")
+		So(usageItems[1].right, ShouldEqual, "Password (default ****** - override $PASSWORD)")
+	})
 }
 
 func TestCmd_usageContent(t *testing.T) {
@@ -117,36 +162,127 @@ func TestCmd_usageContent(t *testing.T) {
 	})
 }
 
-func TestCmd_isTest(t *testing.T) {
-	Convey("should return whether it's a test", t, func() {
-		cmd, err := New(Options{Name: "test"})
+func TestCmd_printTrace(t *testing.T) {
+	Convey("should print the parse trace", t, func() {
+		os.Args = []string{"./app", "-f", "qux"}
+		cmd, err := New(Options{
+			Name: "test",
+			Flags: &struct {
+				Foo bool `short:"f" long:"foo"`
+				Qux struct {
+				} `command:"qux"`
+			}{},
+		})
 		So(err, ShouldBeNil)
 		So(cmd, ShouldNotBeNil)
-		So(cmd.isTest(), ShouldEqual, true)
 
-		os.Args = []string{"./app", "-test."}
-		cmd, err = New(Options{Name: "test"})
-		So(err, ShouldBeNil)
-		So(cmd, ShouldNotBeNil)
-		So(cmd.isTest(), ShouldEqual, true)
+		var buf bytes.Buffer
+		cmd.printTrace(&buf)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		So(lines, ShouldHaveLength, 4)
+		So(lines[0], ShouldStartWith, "INDEX\tTOKEN\tKIND   \tCOMMAND\tFLAG\tVALUE BY\tSOURCE\tSTEPS")
+		So(lines[1], ShouldEqual, "0    \t./app\tprogram")
+		So(lines[2], ShouldEqual, "1    \t-f   \tflag   \t       \tFoo \targ     \t      \ttop level arg")
+		So(lines[3], ShouldEqual, "2    \tqux  \tcommand\tQux    \t    \t        \t      \tcommand argID matched argIndex, found in the arguments, last loop")
 		resetArgs()
+	})
+}
 
-		os.Args = []string{"./app"}
-		cmd, err = New(Options{Name: "test"})
+func TestCmd_ValidateHandlers(t *testing.T) {
+	defer func(v []*FlagHandler) { flagHandlers = v }(flagHandlers)
+	flagHandlers = nil
+
+	Convey("should return the errors of the unknown handler names", t, func() {
+		noop := func(cmd *Cmd, args []string) error { return nil }
+		fh1, _ := NewFlagHandler("Math.Sqr", noop)
+		fh2, _ := NewFlagHandler("math.pow", noop)
+		fh3, _ := NewFlagHandler("Foo", noop)
+		fh4, _ := NewHook("", HandlerKindPreRun, noop)
+		fh5, _ := NewFlagHandler("Math.Sqrt", noop)
+		HandleFlag("Math.Sqr", noop)
+		var buf bytes.Buffer
+		cmd, err := NewCmd(Options{
+			Name: "test",
+			Flags: &struct {
+				Math struct {
+					Sqrt struct {
+						Number float64 `short:"n"`
+					} `command:"sqrt"`
+					Pow struct{} `command:"pow"`
+				} `command:"math"`
+				Echo struct{} `command:"echo"`
+			}{},
+			Handlers:            []*FlagHandler{fh1, fh2, fh3, fh4, fh5},
+			Logger:              log.New(&buf, "", 0),
+			WarnMissingHandlers: true,
+		})
+		So(err, ShouldBeNil)
+		So(cmd.Parse([]string{"./app", "math", "sqrt"}), ShouldBeNil)
+
+		errs := cmd.ValidateHandlers()
+		So(errs, ShouldResemble, []error{
+			errors.New("unknown flag Math.Sqr for handler (did you mean Math.Sqrt?)"),
+			errors.New("unknown flag math.pow for handler (did you mean Math.Pow?)"),
+			errors.New("unknown flag Foo for handler"),
+		})
+		So(cmd.Run(context.Background()), ShouldEqual, ExitCodeError)
+		So(buf.String(), ShouldEqual, "warning: command math pow has no handler\nwarning: command echo has no handler\nunknown flag Math.Sqr for handler (did you mean Math.Sqrt?)\n")
+	})
+
+	Convey("should validate the command handler names by default", t, func() {
+		flagHandlers = nil
+		HandleFlag("Foo", func(cmd *Cmd, args []string) error { return nil })
+		fh, _ := NewFlagHandler("Math.Sqr", func(cmd *Cmd, args []string) error { return nil })
+		for _, skip := range []bool{false, true} {
+			_, err := New(Options{
+				Name: "test",
+				Flags: &struct {
+					Math struct {
+						Sqrt struct{} `command:"sqrt"`
+					} `command:"math"`
+				}{},
+				Args:                  []string{"./app", "math", "sqrt"},
+				Handlers:              []*FlagHandler{fh},
+				SkipHandlerValidation: skip,
+				Logger:                log.New(io.Discard, "", 0),
+			})
+			if skip {
+				So(err, ShouldBeNil)
+			} else {
+				So(err, ShouldBeError, errors.New("unknown flag Math.Sqr for handler (did you mean Math.Sqrt?)"))
+			}
+		}
+
+		// Global handlers
+		_, err := New(Options{
+			Name:   "other",
+			Args:   []string{"./app"},
+			Logger: log.New(io.Discard, "", 0),
+		})
 		So(err, ShouldBeNil)
-		So(cmd, ShouldNotBeNil)
-		So(cmd.isTest(), ShouldEqual, false)
-		resetArgs()
+	})
+}
+
+func TestSuggestName(t *testing.T) {
+	Convey("should return the closest name", t, func() {
+		names := []string{"Math", "Math.Sqrt", "Math.Pow", "Echo"}
+		So(suggestName("Math.Sqr", names), ShouldEqual, "Math.Sqrt")
+		So(suggestName("math.sqrt", names), ShouldEqual, "Math.Sqrt")
+		So(suggestName("Ecoh", names), ShouldEqual, "Echo")
+		So(suggestName("Foo", names), ShouldBeEmpty)
+		So(suggestName("", names), ShouldBeEmpty)
+		So(levenshtein("kitten", "sitting"), ShouldEqual, 3)
+		So(levenshtein("", "abc"), ShouldEqual, 3)
 	})
 }
 
 func TestCmd_exit(t *testing.T) {
-	Convey("should not exit", t, func() {
-		os.Args = []string{"gocmd.test"}
-		cmd, err := New(Options{Name: "test"})
+	Convey("should exit by the given exit function", t, func() {
+		exitCode := -1
+		cmd, err := New(Options{Name: "test", Exit: func(code int) { exitCode = code }})
 		So(err, ShouldBeNil)
 		So(cmd, ShouldNotBeNil)
-		cmd.exit(0)
-		resetArgs()
+		cmd.exit(3)
+		So(exitCode, ShouldEqual, 3)
 	})
 }
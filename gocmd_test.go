@@ -4,14 +4,19 @@
 package gocmd_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/devfacet/gocmd/v3"
 	. "github.com/smartystreets/goconvey/convey"
@@ -29,6 +34,8 @@ func resetArgs() {
 	copy(os.Args, osArgs)
 }
 
+func noExit(code int) {}
+
 func TestNew(t *testing.T) {
 	Convey("should create a new command", t, func() {
 		resetArgs()
@@ -101,6 +108,7 @@ func TestNew(t *testing.T) {
 			}{},
 			Logger:      log.New(io.Discard, "", 0),
 			ExitOnError: true,
+			Exit:        noExit,
 		})
 		So(err, ShouldNotBeNil)
 		So(err, ShouldBeError, errors.New("short argument f in Bar field is already defined in Foo field"))
@@ -115,6 +123,7 @@ func TestNew(t *testing.T) {
 			}{},
 			Logger:      log.New(io.Discard, "", 0),
 			ExitOnError: true,
+			Exit:        noExit,
 		})
 		So(err, ShouldNotBeNil)
 		So(err, ShouldBeError, errors.New("argument -f is required"))
@@ -122,6 +131,694 @@ func TestNew(t *testing.T) {
 	})
 }
 
+func TestNew_prompt(t *testing.T) {
+	Convey("should prompt for the missing required arguments", t, func() {
+		resetArgs()
+		os.Args = []string{"gocmd.test", "math", "pow"}
+
+		flags := struct {
+			Token string `short:"t" required:"true" secret:"true"`
+			Math  struct {
+				Pow struct {
+					Base     float64 `short:"b" long:"base" required:"true" description:"Base"`
+					Exponent float64 `short:"e" long:"exponent" required:"true" description:"Exponent"`
+				} `command:"pow"`
+				Sqrt struct {
+					Number float64 `short:"n" long:"number" required:"true" description:"Number"`
+				} `command:"sqrt"`
+			} `command:"math"`
+		}{}
+		stdout := bytes.Buffer{}
+		cmd, err := gocmd.New(gocmd.Options{
+			Flags:    &flags,
+			AnyError: true,
+			Prompt:   true,
+			Stdin:    strings.NewReader("t0k3n\n\ntwo\n2\n3"),
+			Stdout:   &stdout,
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+		So(flags.Token, ShouldEqual, "t0k3n")
+		So(flags.Math.Pow.Base, ShouldEqual, 2)
+		So(flags.Math.Pow.Exponent, ShouldEqual, 3)
+		So(stdout.String(), ShouldEqual, "-t: Base: argument -b (--base) needs a value\nBase: failed to parse 'two' as float64\nBase: Exponent: ")
+
+		resetArgs()
+	})
+
+	Convey("should fail when there is no input", t, func() {
+		resetArgs()
+		os.Args = []string{"gocmd.test"}
+
+		cmd, err := gocmd.New(gocmd.Options{
+			Flags: &struct {
+				Foo string `short:"f" required:"true"`
+			}{},
+			AnyError: true,
+			Prompt:   true,
+			Stdin:    strings.NewReader(""),
+			Stdout:   io.Discard,
+		})
+		So(err, ShouldBeError, errors.New("argument -f is required"))
+		So(cmd, ShouldBeNil)
+
+		resetArgs()
+	})
+
+	Convey("should not prompt when the usage or version is requested", t, func() {
+		for _, arg := range []string{"-h", "--help", "--help-json", "-v", "--vv"} {
+			stdout := bytes.Buffer{}
+			cmd, err := gocmd.NewCmd(gocmd.Options{
+				Name:    "app",
+				Version: "1.0.0",
+				Flags: &struct {
+					Help      bool `short:"h" long:"help" global:"true"`
+					HelpJSON  bool `long:"help-json"`
+					Version   bool `short:"v" long:"version"`
+					VersionEx bool `long:"vv"`
+					Math      struct {
+						Pow struct {
+							Base float64 `short:"b" long:"base" required:"true" description:"Base"`
+						} `command:"pow"`
+					} `command:"math"`
+				}{},
+				ConfigType:   gocmd.ConfigTypeAuto,
+				AutoHelpJSON: true,
+				Prompt:       true,
+				Stdin:        strings.NewReader("2\n"),
+				Stdout:       &stdout,
+				Exit:         noExit,
+			})
+			So(err, ShouldBeNil)
+			if arg == "--help" {
+				So(cmd.Parse([]string{"./app", "math", "pow", arg}), ShouldBeNil)
+			} else {
+				So(cmd.Parse([]string{"./app", arg, "math", "pow"}), ShouldBeNil)
+			}
+			So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+			So(stdout.String(), ShouldNotContainSubstring, "Base:")
+		}
+	})
+
+	Convey("should not ignore the flag errors by the non-global help flag of another scope", t, func() {
+		var stdout bytes.Buffer
+		called := false
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "app",
+			Flags: &struct {
+				Help bool `short:"h" long:"help"`
+				Math struct {
+					Pow struct {
+						Base float64 `short:"b" long:"base" required:"true"`
+					} `command:"pow"`
+				} `command:"math"`
+			}{},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Prompt:     true,
+			Stdin:      strings.NewReader("2\n"),
+			Stdout:     &stdout,
+			Exit:       noExit,
+		})
+		So(err, ShouldBeNil)
+		cmd.HandleFlag("Math.Pow", func(cmd *gocmd.Cmd, args []string) error {
+			called = true
+			return nil
+		})
+		err = cmd.Parse([]string{"./app", "math", "pow", "--help"})
+		So(err, ShouldBeError, errors.New("argument -b (--base) is required for pow command"))
+		So(gocmd.ExitCode(err), ShouldEqual, gocmd.ExitCodeUsage)
+		So(cmd.Run(context.Background()), ShouldNotEqual, gocmd.ExitCodeOK)
+		So(called, ShouldBeFalse)
+		So(stdout.String(), ShouldBeEmpty)
+	})
+}
+
+func TestNew_output(t *testing.T) {
+	Convey("should write the version and errors into the given outputs", t, func() {
+		os.Args = []string{"gocmd.test", "-vv"}
+		var stdout, stderr bytes.Buffer
+		exitCode := -1
+		cmd, err := gocmd.New(gocmd.Options{
+			Name:    "basic",
+			Version: "1.0.0",
+			Flags: &struct {
+				Version   bool `short:"v" long:"version" description:"Display version"`
+				VersionEx bool `long:"vv" description:"Display version (extended)"`
+			}{},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Stdout:     &stdout,
+			Stderr:     &stderr,
+			Exit:       func(code int) { exitCode = code },
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+		So(stdout.String(), ShouldEqual, fmt.Sprintf("App name    : basic\nApp version : 1.0.0\nGo version  : %s\n", runtime.Version()))
+		So(stderr.String(), ShouldBeEmpty)
+		So(exitCode, ShouldEqual, 0)
+
+		os.Args = []string{"gocmd.test", "--foo"}
+		stdout.Reset()
+		exitCode = -1
+		cmd, err = gocmd.New(gocmd.Options{
+			Name: "basic",
+			Flags: &struct {
+				Bar bool `long:"bar"`
+			}{},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Stdout:     &stdout,
+			Stderr:     &stderr,
+			Exit:       func(code int) { exitCode = code },
+		})
+		So(err, ShouldBeError, errors.New("unknown argument: --foo"))
+		So(cmd, ShouldBeNil)
+		So(stdout.String(), ShouldBeEmpty)
+		So(stderr.String(), ShouldEqual, "unknown argument: --foo\n")
+		So(exitCode, ShouldEqual, gocmd.ExitCodeUsage)
+		So(gocmd.ExitCode(err), ShouldEqual, gocmd.ExitCodeUsage)
+
+		resetArgs()
+	})
+}
+
+func TestNew_args(t *testing.T) {
+	Convey("should parse the given arguments instead of os.Args", t, func() {
+		flags := struct {
+			Help bool `short:"h" long:"help"`
+			Foo  struct {
+				Bar int `short:"b" long:"bar"`
+			} `command:"foo"`
+		}{}
+		var stdout bytes.Buffer
+		cmd, err := gocmd.New(gocmd.Options{
+			Name:       "test",
+			Flags:      &flags,
+			Args:       []string{"./app", "foo", "-b", "3"},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Stdout:     &stdout,
+			Exit:       noExit,
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+		So(flags.Foo.Bar, ShouldEqual, 3)
+		So(cmd.FlagArgs("Foo"), ShouldResemble, []string{"foo", "-b=3"})
+		So(stdout.String(), ShouldBeEmpty)
+
+		exitCode := -1
+		cmd, err = gocmd.New(gocmd.Options{
+			Name:       "test",
+			Flags:      &flags,
+			Args:       []string{"./app"},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Stdout:     &stdout,
+			Exit:       func(code int) { exitCode = code },
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+		So(stdout.String(), ShouldStartWith, "Usage: test [options...] COMMAND [options...]")
+		So(exitCode, ShouldEqual, 0)
+	})
+}
+
+func TestNewCmd(t *testing.T) {
+	Convey("should parse and run the command", t, func() {
+		type ctxKey struct{}
+		flags := struct {
+			Foo struct {
+				Bar int `short:"b" long:"bar" required:"true"`
+			} `command:"foo"`
+		}{}
+		var buf bytes.Buffer
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name:   "test",
+			Flags:  &flags,
+			Logger: log.New(&buf, "", 0),
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 1)
+		So(buf.String(), ShouldEqual, "arguments are not parsed\n")
+
+		var ctxValue interface{}
+		fh, err := cmd.HandleFlag("Foo", func(cmd *gocmd.Cmd, args []string) error {
+			ctxValue = cmd.Context().Value(ctxKey{})
+			if flags.Foo.Bar < 0 {
+				return errors.New("negative bar")
+			}
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(fh, ShouldNotBeNil)
+		So(cmd.Context(), ShouldNotBeNil)
+
+		So(cmd.Parse([]string{"./app", "foo", "-b", "3"}), ShouldBeNil)
+		So(flags.Foo.Bar, ShouldEqual, 3)
+		So(cmd.Run(context.WithValue(context.Background(), ctxKey{}, "value")), ShouldEqual, 0)
+		So(ctxValue, ShouldEqual, "value")
+
+		buf.Reset()
+		So(cmd.Parse([]string{"./app", "foo", "-b=-1"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 1)
+		So(buf.String(), ShouldEqual, "negative bar\n")
+
+		buf.Reset()
+		So(cmd.Parse([]string{"./app", "foo", "-b=x"}), ShouldBeNil)
+		So(cmd.FlagErrors(), ShouldNotBeEmpty)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+	})
+
+	Convey("should fail to parse the arguments", t, func() {
+		var buf bytes.Buffer
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Foo int `long:"foo"`
+			}{},
+			Logger:     log.New(&buf, "", 0),
+			ConfigType: gocmd.ConfigTypeAuto,
+			Exit:       noExit,
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+		So(cmd.Parse([]string{"./app", "--foo=x"}), ShouldBeError, errors.New("failed to parse 'x' as int"))
+		So(cmd.Run(context.Background()), ShouldEqual, 1)
+		So(buf.String(), ShouldEqual, "arguments are not parsed\n")
+	})
+
+	Convey("should ignore the global handlers", t, func() {
+		var calls []string
+		_, err := gocmd.HandleFlag("Isolated", func(cmd *gocmd.Cmd, args []string) error {
+			calls = append(calls, "global")
+			return nil
+		})
+		So(err, ShouldBeNil)
+		for _, ignore := range []bool{false, true} {
+			cmd, err := gocmd.NewCmd(gocmd.Options{
+				Name: "test",
+				Flags: &struct {
+					Isolated struct{} `command:"isolated"`
+				}{},
+				IgnoreGlobalHandlers: ignore,
+			})
+			So(err, ShouldBeNil)
+			cmd.HandleFlag("Isolated", func(cmd *gocmd.Cmd, args []string) error {
+				calls = append(calls, "local")
+				return nil
+			})
+			So(cmd.Parse([]string{"./app", "isolated"}), ShouldBeNil)
+			So(cmd.Run(context.Background()), ShouldEqual, 0)
+		}
+		So(calls, ShouldResemble, []string{"global", "local", "local"})
+	})
+}
+
+func TestCmd_Run_dispatch(t *testing.T) {
+	newCmd := func(mode gocmd.DispatchMode, calls *[]string, buf *bytes.Buffer) *gocmd.Cmd {
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Verbose bool `short:"v"`
+				Math    struct {
+					Sqrt struct {
+						Number float64 `short:"n"`
+					} `command:"sqrt"`
+					Pow struct {
+						Base float64 `short:"b"`
+					} `command:"pow"`
+				} `command:"math"`
+			}{},
+			DispatchMode: mode,
+			Logger:       log.New(buf, "", 0),
+		})
+		So(err, ShouldBeNil)
+		handle := func(name string, kind gocmd.HandlerKind) {
+			fh, err := cmd.HandleFlag(name, func(cmd *gocmd.Cmd, args []string) error {
+				*calls = append(*calls, fmt.Sprintf("%s:%d", name, kind))
+				return nil
+			})
+			So(err, ShouldBeNil)
+			fh.SetKind(kind)
+		}
+		handle("Verbose", gocmd.HandlerKindRun)
+		handle("Math.Pow", gocmd.HandlerKindPersistentPostRun)
+		handle("Math.Pow", gocmd.HandlerKindRun)
+		handle("Math", gocmd.HandlerKindRun)
+		handle("Math", gocmd.HandlerKindPersistentPostRun)
+		handle("Math.Pow", gocmd.HandlerKindPersistentPreRun)
+		handle("Math", gocmd.HandlerKindPersistentPreRun)
+		return cmd
+	}
+
+	Convey("should run all the handlers", t, func() {
+		var calls []string
+		var buf bytes.Buffer
+		cmd := newCmd(gocmd.DispatchAll, &calls, &buf)
+		So(cmd.Parse([]string{"./app", "-v", "math", "pow", "-b", "2"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+		So(calls, ShouldResemble, []string{"Math:1", "Math.Pow:1", "Verbose:0", "Math.Pow:0", "Math:0", "Math.Pow:2", "Math:2"})
+	})
+
+	Convey("should run only the deepest command handler", t, func() {
+		var calls []string
+		var buf bytes.Buffer
+		cmd := newCmd(gocmd.DispatchDeepest, &calls, &buf)
+		So(cmd.Parse([]string{"./app", "-v", "math", "pow", "-b", "2"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+		So(calls, ShouldResemble, []string{"Math:1", "Math.Pow:1", "Math.Pow:0", "Math.Pow:2", "Math:2"})
+
+		calls = nil
+		So(cmd.Parse([]string{"./app", "-v"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+		So(calls, ShouldBeNil)
+	})
+
+	Convey("should fail when the deepest command has no handler", t, func() {
+		var calls []string
+		var buf bytes.Buffer
+		cmd := newCmd(gocmd.DispatchDeepest, &calls, &buf)
+		So(cmd.Parse([]string{"./app", "math", "sqrt", "-n", "4"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 1)
+		So(calls, ShouldBeNil)
+		So(buf.String(), ShouldEqual, "no handler for command math sqrt\n")
+
+		exitCode := -1
+		_, err := gocmd.New(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Foo struct{} `command:"foo"`
+			}{},
+			Args:         []string{"./app", "foo"},
+			DispatchMode: gocmd.DispatchDeepest,
+			Logger:       log.New(io.Discard, "", 0),
+			Exit:         func(code int) { exitCode = code },
+		})
+		So(errors.Is(err, gocmd.ErrNoHandler), ShouldBeTrue)
+		So(exitCode, ShouldEqual, 1)
+	})
+}
+
+func TestCmd_Run_hooks(t *testing.T) {
+	Convey("should run the hooks and middlewares", t, func() {
+		var calls []string
+		record := func(name string) func(cmd *gocmd.Cmd, args []string) error {
+			return func(cmd *gocmd.Cmd, args []string) error {
+				calls = append(calls, name)
+				return nil
+			}
+		}
+		fh, err := gocmd.NewHook("", gocmd.HandlerKindPersistentPreRun, record("app:persistent-pre"))
+		So(err, ShouldBeNil)
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Math struct {
+					Pow struct {
+						Base float64 `short:"b"`
+					} `command:"pow"`
+					Sqrt struct{} `command:"sqrt"`
+				} `command:"math"`
+			}{},
+			Handlers: []*gocmd.FlagHandler{fh},
+			Middlewares: []gocmd.Middleware{
+				func(fh *gocmd.FlagHandler, next gocmd.HandlerFunc) gocmd.HandlerFunc {
+					return func(cmd *gocmd.Cmd, args []string) error {
+						calls = append(calls, "mw1:"+fh.Name())
+						return next(cmd, args)
+					}
+				},
+			},
+			DispatchMode: gocmd.DispatchDeepest,
+			Logger:       log.New(io.Discard, "", 0),
+		})
+		So(err, ShouldBeNil)
+		cmd.Use(func(fh *gocmd.FlagHandler, next gocmd.HandlerFunc) gocmd.HandlerFunc {
+			return func(cmd *gocmd.Cmd, args []string) error {
+				calls = append(calls, "mw2:"+fh.Name())
+				return next(cmd, args)
+			}
+		})
+		for _, v := range []struct {
+			name string
+			kind gocmd.HandlerKind
+		}{
+			{"", gocmd.HandlerKindPostRun},
+			{"", gocmd.HandlerKindPreRun},
+			{"Math", gocmd.HandlerKindPreRun},
+			{"Math", gocmd.HandlerKindPersistentPreRun},
+			{"Math.Pow", gocmd.HandlerKindPostRun},
+			{"Math.Pow", gocmd.HandlerKindPreRun},
+			{"Math.Sqrt", gocmd.HandlerKindPreRun},
+		} {
+			fh, err := cmd.AddHook(v.name, v.kind, record(fmt.Sprintf("%s:%d", v.name, v.kind)))
+			So(err, ShouldBeNil)
+			So(fh.Kind(), ShouldEqual, v.kind)
+		}
+		_, err = cmd.HandleFlag("Math.Pow", record("Math.Pow:run"))
+		So(err, ShouldBeNil)
+
+		So(cmd.Parse([]string{"./app", "math", "pow", "-b", "2"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+		So(calls, ShouldResemble, []string{"app:persistent-pre", "Math:1", ":3", "Math.Pow:3", "mw1:Math.Pow", "mw2:Math.Pow", "Math.Pow:run", "Math.Pow:4", ":4"})
+	})
+
+	Convey("should stop at the failed hook", t, func() {
+		var calls []string
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Foo struct{} `command:"foo"`
+			}{},
+			Logger: log.New(io.Discard, "", 0),
+		})
+		So(err, ShouldBeNil)
+		_, err = cmd.AddHook("", gocmd.HandlerKindPreRun, func(cmd *gocmd.Cmd, args []string) error {
+			return errors.New("unauthorized")
+		})
+		So(err, ShouldBeNil)
+		_, err = cmd.HandleFlag("Foo", func(cmd *gocmd.Cmd, args []string) error {
+			calls = append(calls, "Foo")
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(cmd.Parse([]string{"./app", "foo"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 1)
+		So(calls, ShouldBeNil)
+
+		_, err = gocmd.NewHook("", gocmd.HandlerKindRun, nil)
+		So(err, ShouldBeError, errors.New("invalid hook kind"))
+
+		fh, err := gocmd.NewHook("", gocmd.HandlerKindPreRun, nil)
+		So(err, ShouldBeNil)
+		fh.SetKind(gocmd.HandlerKindRun)
+		So(fh.Kind(), ShouldEqual, gocmd.HandlerKindPreRun)
+	})
+}
+
+func TestExitError(t *testing.T) {
+	Convey("should return the exit codes of the errors", t, func() {
+		So(gocmd.ExitCode(nil), ShouldEqual, gocmd.ExitCodeOK)
+		So(gocmd.ExitCode(errors.New("error")), ShouldEqual, gocmd.ExitCodeError)
+		So(gocmd.ExitCode(&gocmd.ExitError{Code: 3}), ShouldEqual, 3)
+		So(gocmd.ExitCode(fmt.Errorf("wrapped: %w", &gocmd.ExitError{Code: 4})), ShouldEqual, 4)
+
+		So((&gocmd.ExitError{Code: 3}).Error(), ShouldEqual, "exit status 3")
+		So((&gocmd.ExitError{Code: 3, Message: "failed"}).Error(), ShouldEqual, "failed")
+		err := errors.New("underlying")
+		So((&gocmd.ExitError{Code: 3, Err: err}).Error(), ShouldEqual, "underlying")
+		So(errors.Is(&gocmd.ExitError{Code: 3, Err: err}, err), ShouldBeTrue)
+	})
+
+	Convey("should exit with the code of the handler errors", t, func() {
+		var stderr bytes.Buffer
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Foo struct{} `command:"foo"`
+				Bar struct{} `command:"bar"`
+			}{},
+			Stderr: &stderr,
+		})
+		So(err, ShouldBeNil)
+		cmd.HandleFlag("Foo", func(cmd *gocmd.Cmd, args []string) error {
+			return &gocmd.ExitError{Code: 3, Message: "foo failed"}
+		})
+		cmd.HandleFlag("Bar", func(cmd *gocmd.Cmd, args []string) error {
+			return &gocmd.ExitError{Code: 4, Message: "bar failed", Silent: true}
+		})
+
+		So(cmd.Parse([]string{"./app", "foo"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 3)
+		So(stderr.String(), ShouldEqual, "foo failed\n")
+
+		stderr.Reset()
+		So(cmd.Parse([]string{"./app", "bar"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 4)
+		So(stderr.String(), ShouldBeEmpty)
+
+		exitCode := -1
+		fh, _ := gocmd.NewFlagHandler("Foo", func(cmd *gocmd.Cmd, args []string) error {
+			return &gocmd.ExitError{Code: 5, Silent: true}
+		})
+		_, err = gocmd.New(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Foo struct{} `command:"foo"`
+			}{},
+			Args:     []string{"./app", "foo"},
+			Handlers: []*gocmd.FlagHandler{fh},
+			Stderr:   &stderr,
+			Exit:     func(code int) { exitCode = code },
+		})
+		So(gocmd.ExitCode(err), ShouldEqual, 5)
+		So(exitCode, ShouldEqual, 5)
+		So(stderr.String(), ShouldBeEmpty)
+	})
+
+	Convey("should exit with the usage code of the config, env and response file errors", t, func() {
+		dir := t.TempDir()
+		config := filepath.Join(dir, "config.ini")
+		So(os.WriteFile(config, []byte("[foo\n"), 0600), ShouldBeNil)
+		tests := []gocmd.Options{
+			{ConfigFiles: []string{config}},
+			{EnvFiles: []string{filepath.Join(dir, "missing.env")}},
+			{ResponseFiles: true},
+		}
+		for _, o := range tests {
+			o.Name = "test"
+			o.Flags = &struct {
+				Foo string `long:"foo" env:"FOO"`
+			}{}
+			cmd, err := gocmd.NewCmd(o)
+			So(err, ShouldBeNil)
+			err = cmd.Parse([]string{"./app", "@" + filepath.Join(dir, "missing.txt")})
+			So(err, ShouldNotBeNil)
+			So(gocmd.ExitCode(err), ShouldEqual, gocmd.ExitCodeUsage)
+		}
+	})
+}
+
+func TestCmd_Timeout(t *testing.T) {
+	newCmd := func(stderr *bytes.Buffer) *gocmd.Cmd {
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Timeout string `long:"timeout"`
+				Batch   struct {
+					Run struct {
+						Wait bool `long:"wait"`
+					} `command:"run"`
+				} `command:"batch" timeout:"1h"`
+				Quick struct{} `command:"quick"`
+			}{},
+			Stderr: stderr,
+		})
+		So(err, ShouldBeNil)
+		cmd.HandleFlag("Batch.Run", func(cmd *gocmd.Cmd, args []string) error {
+			if cmd.FlagValue("Batch.Run.Wait") == true {
+				<-cmd.Context().Done()
+				return cmd.Context().Err()
+			}
+			return nil
+		})
+		cmd.HandleFlag("Quick", func(cmd *gocmd.Cmd, args []string) error {
+			return cmd.Context().Err()
+		})
+		return cmd
+	}
+
+	Convey("should return the timeout of the command", t, func() {
+		var stderr bytes.Buffer
+		cmd := newCmd(&stderr)
+		So(cmd.Parse([]string{"./app", "batch", "run"}), ShouldBeNil)
+		d, err := cmd.Timeout()
+		So(err, ShouldBeNil)
+		So(d, ShouldEqual, time.Hour)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+
+		So(cmd.Parse([]string{"./app", "--timeout", "5m", "batch", "run"}), ShouldBeNil)
+		d, err = cmd.Timeout()
+		So(err, ShouldBeNil)
+		So(d, ShouldEqual, 5*time.Minute)
+
+		So(cmd.Parse([]string{"./app", "quick"}), ShouldBeNil)
+		d, err = cmd.Timeout()
+		So(err, ShouldBeNil)
+		So(d, ShouldEqual, 0)
+	})
+
+	Convey("should fail when the handlers exceed the timeout", t, func() {
+		var stderr bytes.Buffer
+		cmd := newCmd(&stderr)
+		So(cmd.Parse([]string{"./app", "--timeout=10ms", "batch", "run", "--wait"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeTimeout)
+		So(stderr.String(), ShouldEqual, "command timed out after 10ms\n")
+
+		exitCode := -1
+		fh, _ := gocmd.NewFlagHandler("Wait", func(cmd *gocmd.Cmd, args []string) error {
+			<-cmd.Context().Done()
+			return nil
+		})
+		_, err := gocmd.New(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Wait struct{} `command:"wait" timeout:"10ms"`
+			}{},
+			Args:     []string{"./app", "wait"},
+			Handlers: []*gocmd.FlagHandler{fh},
+			Logger:   log.New(io.Discard, "", 0),
+			Exit:     func(code int) { exitCode = code },
+		})
+		So(errors.Is(err, gocmd.ErrTimeout), ShouldBeTrue)
+		So(exitCode, ShouldEqual, gocmd.ExitCodeTimeout)
+
+		stderr.Reset()
+		cmd = newCmd(&stderr)
+		So(cmd.Parse([]string{"./app", "--timeout=soon", "batch", "run"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeUsage)
+		So(stderr.String(), ShouldEqual, "invalid timeout value soon\n")
+	})
+
+	Convey("should not use the timeout flag for the commands those have no timeout tag", t, func() {
+		for _, v := range []string{"--timeout=soon", "--timeout=10ms"} {
+			var stderr bytes.Buffer
+			cmd := newCmd(&stderr)
+			So(cmd.Parse([]string{"./app", v, "quick"}), ShouldBeNil)
+			d, err := cmd.Timeout()
+			So(err, ShouldBeNil)
+			So(d, ShouldEqual, 0)
+			So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+			So(stderr.String(), ShouldBeEmpty)
+		}
+
+		var timeout string
+		fh, _ := gocmd.NewFlagHandler("Get", func(cmd *gocmd.Cmd, args []string) error {
+			timeout = cmd.FlagValue("Timeout").(string)
+			return nil
+		})
+		_, err := gocmd.New(gocmd.Options{
+			Name: "http",
+			Flags: &struct {
+				Timeout string   `long:"timeout" description:"Request timeout in seconds"`
+				Get     struct{} `command:"get"`
+			}{},
+			Args:     []string{"./app", "--timeout=30", "get"},
+			Handlers: []*gocmd.FlagHandler{fh},
+			Exit:     noExit,
+		})
+		So(err, ShouldBeNil)
+		So(timeout, ShouldEqual, "30")
+	})
+
+	Convey("should not fail by the timeout when the parent context deadline is exceeded", t, func() {
+		var stderr bytes.Buffer
+		cmd := newCmd(&stderr)
+		So(cmd.Parse([]string{"./app", "batch", "run", "--wait"}), ShouldBeNil)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		So(cmd.Run(ctx), ShouldEqual, gocmd.ExitCodeError)
+		So(stderr.String(), ShouldEqual, "context deadline exceeded\n")
+	})
+}
+
 func TestCmd_Name(t *testing.T) {
 	Convey("should return the correct command name", t, func() {
 		cmd, err := gocmd.New(gocmd.Options{
@@ -155,6 +852,230 @@ func TestCmd_Description(t *testing.T) {
 	})
 }
 
+func TestCmd_Schema(t *testing.T) {
+	Convey("should return the schema of the command", t, func() {
+		cmd, err := gocmd.New(gocmd.Options{
+			Name:        "test",
+			Version:     "1.0.0",
+			Description: "Test",
+			Flags: &struct {
+				Foo bool `short:"f" long:"foo" description:"Test foo"`
+				Bar struct {
+					Baz string `long:"baz" default:"qux"`
+				} `command:"bar" description:"Bar command"`
+			}{},
+		})
+		So(err, ShouldBeNil)
+		So(cmd, ShouldNotBeNil)
+
+		schema := cmd.Schema()
+		So(schema.Name, ShouldEqual, "test")
+		So(schema.Version, ShouldEqual, "1.0.0")
+		So(schema.Description, ShouldEqual, "Test")
+		So(schema.Flags, ShouldHaveLength, 1)
+		So(schema.Flags[0].Long, ShouldEqual, "foo")
+		So(schema.Commands, ShouldHaveLength, 1)
+		So(schema.Commands[0].Command, ShouldEqual, "bar")
+		So(schema.Commands[0].Flags[0].Default, ShouldEqual, "qux")
+
+		cmd, err = gocmd.New(gocmd.Options{Name: "test"})
+		So(err, ShouldBeNil)
+		So(cmd.Schema().Flags, ShouldBeNil)
+	})
+
+	Convey("should not print the schema by the auto config type", t, func() {
+		var stdout bytes.Buffer
+		called := false
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				HelpJSON bool `long:"help-json"`
+			}{},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Stdout:     &stdout,
+		})
+		So(err, ShouldBeNil)
+		cmd.HandleFlag("HelpJSON", func(cmd *gocmd.Cmd, args []string) error {
+			called = true
+			return nil
+		})
+		So(cmd.Parse([]string{"./app", "--help-json"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(called, ShouldBeTrue)
+		So(stdout.String(), ShouldBeEmpty)
+	})
+}
+
+func TestCmd_help(t *testing.T) {
+	newCmd := func(stdout *bytes.Buffer) *gocmd.Cmd {
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "basic",
+			Flags: &struct {
+				Help  bool `short:"h" long:"help" description:"Display usage" global:"true"`
+				Debug bool `long:"debug" description:"Debug"`
+				Math  struct {
+					Precision int `short:"p" long:"precision" description:"Precision"`
+					Pow       struct {
+						Base     float64 `short:"b" long:"base" required:"true" description:"Base"`
+						Exponent float64 `short:"e" long:"exponent" default:"2" description:"Exponent"`
+					} `command:"pow" description:"Calculate base exponential"`
+					Sqrt struct{} `command:"sqrt" description:"Calculate square root"`
+				} `command:"math" description:"Math functions"`
+			}{},
+			ConfigType:  gocmd.ConfigTypeAuto,
+			HelpCommand: true,
+			Stdout:      stdout,
+			Stderr:      io.Discard,
+		})
+		So(err, ShouldBeNil)
+		return cmd
+	}
+
+	Convey("should print the usage of the command", t, func() {
+		var stdout bytes.Buffer
+		cmd := newCmd(&stdout)
+		So(cmd.Parse([]string{"./app", "math", "pow", "--help"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "Usage: basic math pow [options...]\n\nCalculate base exponential\n\nOptions:\n  -b, --base     \tBase\n  -e, --exponent \tExponent (default 2)\n\nGlobal Options:\n  -h, --help     \tDisplay usage\n\n")
+	})
+
+	Convey("should print the usage by the help command", t, func() {
+		var stdout bytes.Buffer
+		cmd := newCmd(&stdout)
+		So(cmd.Parse([]string{"./app", "help", "math"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "Usage: basic math [options...] COMMAND [options...]\n\nMath functions\n\nOptions:\n  -p, --precision \tPrecision\n\nGlobal Options:\n  -h, --help      \tDisplay usage\n\nCommands:\n  pow             \tCalculate base exponential\n  sqrt            \tCalculate square root\n\n")
+
+		stdout.Reset()
+		So(cmd.Parse([]string{"./app", "help"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "Usage: basic [options...] COMMAND [options...]\n\nOptions:\n  -h, --help         \tDisplay usage\n      --debug        \tDebug\n\nCommands:\n  math               \tMath functions\n    -p, --precision  \tPrecision\n    pow              \tCalculate base exponential\n      -b, --base     \tBase\n      -e, --exponent \tExponent (default 2)\n    sqrt             \tCalculate square root\n  help [command...]  \tDisplay the usage of a command\n\n")
+
+		stdout.Reset()
+		So(cmd.Parse([]string{"./app", "help", "math", "foo"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeUsage)
+		So(stdout.String(), ShouldBeEmpty)
+	})
+
+	Convey("should print the usage of the command by the non-global help flag before the command only", t, func() {
+		var stdout bytes.Buffer
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "basic",
+			Flags: &struct {
+				Help bool `short:"h" long:"help" description:"Display usage"`
+				Math struct {
+					Pow struct {
+						Base float64 `short:"b" long:"base" required:"true" description:"Base"`
+					} `command:"pow" description:"Calculate base exponential"`
+				} `command:"math" description:"Math functions"`
+			}{},
+			ConfigType: gocmd.ConfigTypeAuto,
+			Stdout:     &stdout,
+			Stderr:     io.Discard,
+		})
+		So(err, ShouldBeNil)
+		So(cmd.Parse([]string{"./app", "--help", "math", "pow"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "Usage: basic math pow [options...]\n\nCalculate base exponential\n\nOptions:\n  -b, --base \tBase\n\n")
+
+		stdout.Reset()
+		err = cmd.Parse([]string{"./app", "math", "pow", "--help"})
+		So(err, ShouldBeError, errors.New("argument -b (--base) is required for pow command"))
+		So(stdout.String(), ShouldBeEmpty)
+	})
+}
+
+func TestCmd_usageTemplate(t *testing.T) {
+	newCmd := func(stdout *bytes.Buffer) *gocmd.Cmd {
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "basic",
+			Flags: &struct {
+				Help bool `short:"h" long:"help" description:"Display usage" global:"true"`
+				Math struct {
+					Pow struct {
+						Base  float64 `short:"b" long:"base" env:"BASE" description:"Base"`
+						Token string  `long:"token" default:"t0k3n" secret:"true" description:"Token"`
+					} `command:"pow" description:"Calculate base exponential"`
+				} `command:"math" description:"Math functions"`
+			}{},
+			ConfigType:    gocmd.ConfigTypeAuto,
+			UsageTemplate: `{{ .Synopsis }}{{ range .Commands }} {{ .Line }}{{ end }}`,
+			UsageTemplates: map[string]string{
+				"Math.Pow": `{{ .Command.Line }}:{{ range .Flags }} {{ .Long }}={{ .Type }},{{ .Default }},{{ .Env }}{{ end }}{{ range .GlobalFlags }} {{ .Name }}{{ end }}`,
+			},
+			Stdout: stdout,
+			Stderr: io.Discard,
+		})
+		So(err, ShouldBeNil)
+		return cmd
+	}
+
+	Convey("should print the usage by the templates", t, func() {
+		var stdout bytes.Buffer
+		cmd := newCmd(&stdout)
+		So(cmd.Parse([]string{"./app", "-h"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "basic [options...] COMMAND [options...] math\n")
+
+		stdout.Reset()
+		So(cmd.Parse([]string{"./app", "math", "--help"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "basic math [options...] COMMAND [options...] math pow\n")
+
+		stdout.Reset()
+		So(cmd.Parse([]string{"./app", "math", "pow", "--help"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "math pow: base=float64,,BASE token=string,******, Help\n")
+	})
+
+	Convey("should fail to create a command by an invalid template", t, func() {
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name:           "basic",
+			UsageTemplates: map[string]string{"Math": "{{ .Name"},
+		})
+		So(err, ShouldBeError, errors.New("failed to parse template due to template: usage Math:1: unclosed action"))
+		So(cmd, ShouldBeNil)
+	})
+
+	Convey("should fail to parse the arguments by an unknown template path", t, func() {
+		for k, v := range map[string]string{
+			"Math.Pw":       "unknown command Math.Pw for usage template (did you mean Math.Pow?)",
+			"Foo":           "unknown command Foo for usage template",
+			"Math.Pow.Base": "flag Math.Pow.Base is not a command for usage template",
+		} {
+			cmd, err := gocmd.NewCmd(gocmd.Options{
+				Name: "basic",
+				Flags: &struct {
+					Math struct {
+						Pow struct {
+							Base float64 `short:"b" long:"base"`
+						} `command:"pow"`
+					} `command:"math"`
+				}{},
+				UsageTemplates: map[string]string{k: "{{ .Name }}"},
+			})
+			So(err, ShouldBeNil)
+			So(cmd.Parse([]string{"./app"}), ShouldBeError, errors.New(v))
+		}
+	})
+
+	Convey("should replace the tabs of the descriptions", t, func() {
+		var stdout bytes.Buffer
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "basic",
+			Flags: &struct {
+				Help bool `short:"h" long:"help" description:"Display\tusage"`
+			}{},
+			AutoHelp: true,
+			Stdout:   &stdout,
+		})
+		So(err, ShouldBeNil)
+		So(cmd.Parse([]string{"./app", "-h"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeOK)
+		So(stdout.String(), ShouldEqual, "Usage: basic [options...]\n\nOptions:\n  -h, --help \tDisplay usage\n\n\n")
+	})
+}
+
 func TestCmd_LookupFlag(t *testing.T) {
 	Convey("should lookup a flag", t, func() {
 		resetArgs()
@@ -264,6 +1185,7 @@ func TestHandleFlag(t *testing.T) {
 			return nil
 		})
 		fh.SetPriority(2)
+		exitCode := -1
 		cmd, err := gocmd.New(gocmd.Options{
 			Name:        "test",
 			Version:     "1.0.0",
@@ -273,11 +1195,75 @@ func TestHandleFlag(t *testing.T) {
 				FH2 struct{} `command:"fh2"`
 			}{},
 			Logger: log.New(io.Discard, "", 0),
+			Exit:   func(code int) { exitCode = code },
 		})
 		So(err, ShouldNotBeNil)
 		So(err, ShouldBeError, errors.New("handler error"))
 		So(cmd, ShouldBeNil)
 		So(fhCnt, ShouldEqual, 2)
+		So(exitCode, ShouldEqual, 1)
+
+		resetArgs()
+	})
+}
+
+func TestNewFlagHandler(t *testing.T) {
+	Convey("should fail to create flag handler", t, func() {
+		fh, err := gocmd.NewFlagHandler("", func(cmd *gocmd.Cmd, args []string) error {
+			return nil
+		})
+		So(err, ShouldBeError, errors.New("invalid flag name"))
+		So(fh, ShouldBeNil)
+	})
+
+	Convey("should run the command specific flag handlers", t, func() {
+		os.Args = []string{"gocmd.test", "foo"}
+		var calls []string
+		newHandler := func(name string, priority int) *gocmd.FlagHandler {
+			fh, err := gocmd.NewFlagHandler(name, func(cmd *gocmd.Cmd, args []string) error {
+				calls = append(calls, fmt.Sprintf("%s:%s", cmd.Name(), name))
+				return nil
+			})
+			So(err, ShouldBeNil)
+			fh.SetPriority(priority)
+			return fh
+		}
+		flags := func() interface{} {
+			return &struct {
+				Foo struct{} `command:"foo"`
+				Bar struct{} `command:"bar"`
+			}{}
+		}
+
+		cmd1, err := gocmd.New(gocmd.Options{
+			Name:     "app1",
+			Flags:    flags(),
+			Handlers: []*gocmd.FlagHandler{newHandler("Foo", 2), nil, newHandler("Bar", 0), newHandler("Foo", 1)},
+		})
+		So(err, ShouldBeNil)
+		So(cmd1, ShouldNotBeNil)
+		cmd2, err := gocmd.New(gocmd.Options{
+			Name:  "app2",
+			Flags: flags(),
+		})
+		So(err, ShouldBeNil)
+		So(cmd2, ShouldNotBeNil)
+		So(calls, ShouldResemble, []string{"app1:Foo", "app1:Foo"})
+
+		calls = nil
+		fh, err := gocmd.NewFlagHandler("Foo", func(cmd *gocmd.Cmd, args []string) error {
+			return errors.New("handler error")
+		})
+		So(err, ShouldBeNil)
+		fh.SetExitOnError(false)
+		cmd, err := gocmd.New(gocmd.Options{
+			Name:     "app3",
+			Flags:    flags(),
+			Handlers: []*gocmd.FlagHandler{fh, newHandler("Foo", 1)},
+		})
+		So(err, ShouldBeError, errors.New("handler error"))
+		So(cmd, ShouldBeNil)
+		So(calls, ShouldBeNil)
 
 		resetArgs()
 	})
@@ -308,6 +1294,7 @@ func ExampleNew_usage() {
 			} `command:"math" description:"Math functions"`
 		}{},
 		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
 	})
 	// Output:
 	// Usage: basic [options...] COMMAND [options...]
@@ -342,6 +1329,7 @@ func ExampleNew_usage_h() {
 			Help bool `short:"h" long:"help" description:"Display usage" global:"true"`
 		}{},
 		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
 	})
 	// Output:
 	// Usage: basic [options...]
@@ -365,6 +1353,7 @@ func ExampleNew_usage_help() {
 			Help bool `long:"help" description:"Display usage" global:"true"`
 		}{},
 		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
 	})
 	// Output:
 	// Usage: basic [options...]
@@ -377,27 +1366,6 @@ func ExampleNew_usage_help() {
 	resetArgs()
 }
 
-func ExampleNew_version() {
-	os.Args = []string{"gocmd.test", "-vv"}
-
-	gocmd.New(gocmd.Options{
-		Name:        "basic",
-		Version:     "1.0.0",
-		Description: "A basic app",
-		Flags: &struct {
-			Version   bool `short:"v" long:"version" description:"Display version"`
-			VersionEx bool `long:"vv" description:"Display version (extended)"`
-		}{},
-		ConfigType: gocmd.ConfigTypeAuto,
-	})
-	// Output:
-	// App name    : basic
-	// App version : 1.0.0
-	// Go version  : vTest
-
-	resetArgs()
-}
-
 func ExampleNew_version_v() {
 	os.Args = []string{"gocmd.test", "-v"}
 
@@ -409,6 +1377,7 @@ func ExampleNew_version_v() {
 			Version bool `short:"v" long:"version" description:"Display version"`
 		}{},
 		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
 	})
 	// Output:
 	// 1.0.0
@@ -427,6 +1396,7 @@ func ExampleNew_version_version() {
 			Version bool `long:"version" description:"Display version"`
 		}{},
 		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
 	})
 	// Output:
 	// 1.0.0
@@ -478,6 +1448,7 @@ func ExampleNew_command() {
 		Description: "A basic app",
 		Flags:       &flags,
 		ConfigType:  gocmd.ConfigTypeAuto,
+		Exit:        noExit,
 	})
 	// Output:
 	// 3
@@ -489,9 +1460,117 @@ func ExampleCmd_PrintVersion() {
 	cmd, err := gocmd.New(gocmd.Options{
 		Version:    "1.0.0",
 		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
 	})
 	if err == nil {
 		cmd.PrintVersion(false)
 	}
 	// Output: 1.0.0
 }
+
+func ExampleNew_helpJSON() {
+	os.Args = []string{"gocmd.test", "--help-json"}
+
+	gocmd.New(gocmd.Options{
+		Name:        "basic",
+		Version:     "1.0.0",
+		Description: "A basic app",
+		Flags: &struct {
+			HelpJSON bool `long:"help-json" description:"Display usage as JSON"`
+			Math     struct {
+				Sqrt struct {
+					Number float64 `short:"n" long:"number" required:"true" description:"Number"`
+				} `command:"sqrt" description:"Calculate square root"`
+			} `command:"math" description:"Math functions"`
+		}{},
+		ConfigType:   gocmd.ConfigTypeAuto,
+		AutoHelpJSON: true,
+		Exit:         noExit,
+	})
+	// Output:
+	// {
+	//   "name": "basic",
+	//   "version": "1.0.0",
+	//   "description": "A basic app",
+	//   "flags": [
+	//     {
+	//       "name": "HelpJSON",
+	//       "path": "HelpJSON",
+	//       "long": "help-json",
+	//       "type": "bool",
+	//       "description": "Display usage as JSON"
+	//     }
+	//   ],
+	//   "commands": [
+	//     {
+	//       "name": "Math",
+	//       "path": "Math",
+	//       "command": "math",
+	//       "description": "Math functions",
+	//       "commands": [
+	//         {
+	//           "name": "Sqrt",
+	//           "path": "Math.Sqrt",
+	//           "command": "sqrt",
+	//           "description": "Calculate square root",
+	//           "flags": [
+	//             {
+	//               "name": "Number",
+	//               "path": "Math.Sqrt.Number",
+	//               "short": "n",
+	//               "long": "number",
+	//               "type": "float64",
+	//               "description": "Number",
+	//               "required": true,
+	//               "nonempty": true
+	//             }
+	//           ]
+	//         }
+	//       ]
+	//     }
+	//   ]
+	// }
+
+	resetArgs()
+}
+
+func ExampleCmd_Run() {
+	flags := struct {
+		Help bool `short:"h" long:"help" description:"Display usage"`
+		Say  struct {
+			Settings bool `settings:"true" allow-unknown-arg:"true"`
+		} `command:"say" description:"Print arguments"`
+	}{}
+
+	cmd, _ := gocmd.NewCmd(gocmd.Options{
+		Name:       "basic",
+		Flags:      &flags,
+		ConfigType: gocmd.ConfigTypeAuto,
+		Exit:       noExit,
+	})
+	cmd.HandleFlag("Say", func(cmd *gocmd.Cmd, args []string) error {
+		fmt.Println(strings.Join(args[1:], " "))
+		return nil
+	})
+	if err := cmd.Parse([]string{"./app", "say", "hello", "world"}); err != nil {
+		return
+	}
+	fmt.Println(cmd.Run(context.Background()))
+
+	if err := cmd.Parse([]string{"./app", "-h"}); err != nil {
+		return
+	}
+	fmt.Println(cmd.Run(context.Background()))
+	// Output:
+	// hello world
+	// 0
+	// Usage: basic [options...] COMMAND [options...]
+	//
+	// Options:
+	//   -h, --help 	Display usage
+	//
+	// Commands:
+	//   say        	Print arguments
+	//
+	// 0
+}
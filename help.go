@@ -0,0 +1,93 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+)
+
+// HelpCommand is the name of the built-in help command (see Options.HelpCommand)
+const HelpCommand = "help"
+
+// helpCommandArgs returns the command names of the help command (i.e. [math pow] for `app help math pow`)
+// It returns nil when the help command is not present in the given arguments or not enabled.
+func (cmd *Cmd) helpCommandArgs(args []string) []string {
+	if !cmd.helpCommandEnabled() || len(args) < 2 || args[1] != HelpCommand {
+		return nil
+	}
+	return append([]string{}, args[2:]...)
+}
+
+// helpCommandEnabled returns whether the built-in help command is enabled or not
+// User defined help commands override the built-in one.
+func (cmd *Cmd) helpCommandEnabled() bool {
+	if !cmd.options.HelpCommand {
+		return false
+	}
+	rt := reflect.TypeOf(cmd.flags)
+	if rt == nil || rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct {
+		return true
+	}
+	rt = rt.Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		if rt.Field(i).Tag.Get("command") == HelpCommand {
+			return false
+		}
+	}
+	return true
+}
+
+// commandByNames returns the command flag by the given command names (i.e. [math pow])
+// It returns nil for the empty names (i.e. the root command).
+func (cmd *Cmd) commandByNames(names []string) (*flagset.Flag, error) {
+	// Init vars
+	var result *flagset.Flag
+	parentID := -1
+
+	// Iterate over the names
+	for i, name := range names {
+		var found *flagset.Flag
+		for _, flag := range cmd.flagSet.Flags() {
+			if flag.Kind() == "command" && flag.ParentID() == parentID && flag.Command() == name {
+				found = flag
+				break
+			}
+		}
+		if found == nil {
+			return nil, &ExitError{Code: ExitCodeUsage, Err: fmt.Errorf("unknown command: %s", strings.Join(names[:i+1], " "))}
+		}
+		result = found
+		parentID = found.ID()
+	}
+
+	return result, nil
+}
+
+// printHelp prints the usage of the command by the given command names (see Options.HelpCommand)
+func (cmd *Cmd) printHelp(names []string) error {
+	flag, err := cmd.commandByNames(names)
+	if err != nil {
+		return err
+	}
+	usage, err := cmd.renderUsage(flag)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.stdout, usage)
+	return nil
+}
+
+// flagByID returns the flag by the given id or returns nil if it doesn't exist
+func (cmd *Cmd) flagByID(id int) *flagset.Flag {
+	for _, f := range cmd.flagSet.Flags() {
+		if f.ID() == id {
+			return f
+		}
+	}
+	return nil
+}
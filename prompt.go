@@ -0,0 +1,50 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+)
+
+// prompt returns a function that prompts for the flag values by the standard input and output
+// Flag descriptions are used as labels and the inputs of the secret flags are hidden when it's possible.
+func (cmd *Cmd) prompt() func(flag *flagset.Flag, err error) (string, bool) {
+	reader := bufio.NewReader(cmd.stdin)
+	return func(flag *flagset.Flag, err error) (string, bool) {
+		// Print the error of the previous value
+		if err != nil {
+			fmt.Fprintf(cmd.stdout, "%s\n", err)
+		}
+
+		// Print the label
+		label := flag.Description()
+		if label == "" {
+			label = flag.FormattedArg()
+		}
+		fmt.Fprintf(cmd.stdout, "%s: ", label)
+
+		// Hide the input of the secret flags
+		if f, ok := cmd.stdin.(*os.File); ok && flag.Secret() && isTerminal(f) {
+			if restore, err := disableEcho(f); err == nil {
+				defer func() {
+					restore()
+					fmt.Fprintln(cmd.stdout)
+				}()
+			}
+		}
+
+		// Read the value
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", false
+		}
+		return strings.TrimRight(line, "\r\n"), true
+	}
+}
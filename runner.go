@@ -0,0 +1,52 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"context"
+	"reflect"
+)
+
+// Runner is the interface that is implemented by the command structs those run themselves
+// Commands must be named struct types for having methods (i.e. Pow PowCommand field with `command:"pow"` tag).
+// Runners are dispatched like the flag handlers of their commands (see DispatchMode).
+// Command structs can also implement flagset.Validator for validating their values while parsing.
+type Runner interface {
+	Run(ctx context.Context, cmd *Cmd) error
+}
+
+// runnerHandlers returns the flag handlers of the commands those implement Runner interface
+func (cmd *Cmd) runnerHandlers() []*FlagHandler {
+	// Init vars
+	var result []*FlagHandler
+	rv := reflect.ValueOf(cmd.flags)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+
+	// Iterate over the commands
+	for _, flag := range cmd.flagSet.Flags() {
+		if flag.Kind() != "command" {
+			continue
+		}
+		fv := rv.FieldByIndex(flag.FieldIndex())
+		if !fv.CanAddr() {
+			continue
+		}
+		runner, ok := fv.Addr().Interface().(Runner)
+		if !ok {
+			continue
+		}
+		result = append(result, &FlagHandler{
+			name: cmd.flagPath(flag),
+			handler: func(cmd *Cmd, args []string) error {
+				return runner.Run(cmd.Context(), cmd)
+			},
+			exitOnError: true,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/devfacet/gocmd/v3"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type powCommand struct {
+	Base     float64 `short:"b" long:"base" required:"true"`
+	Exponent float64 `short:"e" long:"exponent" required:"true"`
+}
+
+func (c *powCommand) Validate() error {
+	if c.Base == 0 && c.Exponent < 0 {
+		return errors.New("zero base can't have a negative exponent")
+	}
+	return nil
+}
+
+func (c *powCommand) Run(ctx context.Context, cmd *gocmd.Cmd) error {
+	fmt.Fprintln(cmd.Stdout(), math.Pow(c.Base, c.Exponent))
+	return nil
+}
+
+type mathCommand struct {
+	Pow  powCommand `command:"pow"`
+	Sqrt struct {
+		Number float64 `short:"n"`
+	} `command:"sqrt"`
+}
+
+func TestRunner(t *testing.T) {
+	newCmd := func(stdout, stderr *bytes.Buffer) *gocmd.Cmd {
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Math mathCommand `command:"math"`
+			}{},
+			DispatchMode: gocmd.DispatchDeepest,
+			Stdout:       stdout,
+			Stderr:       stderr,
+		})
+		So(err, ShouldBeNil)
+		return cmd
+	}
+
+	Convey("should run the command structs", t, func() {
+		var stdout, stderr bytes.Buffer
+		cmd := newCmd(&stdout, &stderr)
+		So(cmd.Parse([]string{"./app", "math", "pow", "-b", "2", "-e", "3"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+		So(stdout.String(), ShouldEqual, "8\n")
+		So(stderr.String(), ShouldBeEmpty)
+	})
+
+	Convey("should fail to validate the command structs", t, func() {
+		var stdout, stderr bytes.Buffer
+		cmd, err := gocmd.NewCmd(gocmd.Options{
+			Name: "test",
+			Flags: &struct {
+				Math mathCommand `command:"math"`
+			}{},
+			AnyError: true,
+			Stdout:   &stdout,
+			Stderr:   &stderr,
+		})
+		So(err, ShouldBeNil)
+		err = cmd.Parse([]string{"./app", "math", "pow", "-b", "0", "-e=-1"})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "math pow: zero base can't have a negative exponent")
+		So(gocmd.ExitCode(err), ShouldEqual, gocmd.ExitCodeUsage)
+		So(cmd.FlagErrors(), ShouldHaveLength, 1)
+		So(stdout.String(), ShouldBeEmpty)
+	})
+
+	Convey("should fail when the command has no runner", t, func() {
+		var stdout, stderr bytes.Buffer
+		cmd := newCmd(&stdout, &stderr)
+		So(cmd.Parse([]string{"./app", "math", "sqrt", "-n", "4"}), ShouldBeNil)
+		So(cmd.Run(context.Background()), ShouldEqual, gocmd.ExitCodeError)
+		So(stderr.String(), ShouldEqual, "no handler for command math sqrt\n")
+	})
+}
@@ -0,0 +1,124 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// handlerResult represents the result of the handlers
+type handlerResult struct {
+	fh    *FlagHandler
+	err   error
+	panic interface{} // recovered panic value
+}
+
+// dispatch runs the handlers by the given context (see runHandlers)
+// When the command has a timeout, the context has a deadline and the timeout error is an ExitError
+// with ExitCodeTimeout. When the HandleSignals option is set, the first SIGINT or SIGTERM cancels the context
+// and the handlers have the grace period for returning. The second signal or the end of the grace period
+// stops waiting. Signal errors are ExitErrors with 128 + signal number exit codes (i.e. 130 for SIGINT).
+// The handlers run in a goroutine and it's not waited for after it stops waiting (abandoned handlers).
+// Their panics are re-raised in the caller goroutine unless they are abandoned.
+func (cmd *Cmd) dispatch(ctx context.Context) (*FlagHandler, error) {
+	// Init vars
+	timeout, err := cmd.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	if !cmd.options.HandleSignals && timeout == 0 {
+		return cmd.runHandlers(ctx)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var deadline <-chan struct{}
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+		deadline = ctx.Done()
+	}
+	var sigCh chan os.Signal
+	if cmd.options.HandleSignals {
+		sigCh = make(chan os.Signal, 2)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigCh) // restore the default behavior
+	}
+
+	// Run the handlers
+	resCh := make(chan handlerResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				resCh <- handlerResult{panic: r}
+			}
+		}()
+		fh, err := cmd.runHandlers(ctx)
+		resCh <- handlerResult{fh: fh, err: err}
+	}()
+	wait := func(res handlerResult) handlerResult {
+		if res.panic != nil {
+			panic(res.panic)
+		}
+		return res
+	}
+
+	// Wait for the handlers, the deadline or the first signal
+	var sig os.Signal
+	select {
+	case res := <-resCh:
+		res = wait(res)
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+			return nil, timeoutError(timeout)
+		}
+		return res.fh, res.err
+	case <-deadline:
+		if parent.Err() == nil {
+			return nil, timeoutError(timeout) // the handlers are abandoned
+		}
+		res := wait(<-resCh) // canceled by the parent context (i.e. its own deadline)
+		return res.fh, res.err
+	case sig = <-sigCh:
+		cancel()
+	}
+
+	// Wait for the handlers, the grace period or the second signal
+	var grace <-chan time.Time
+	if cmd.options.GracePeriod > 0 {
+		timer := time.NewTimer(cmd.options.GracePeriod)
+		defer timer.Stop()
+		grace = timer.C
+	}
+	select {
+	case res := <-resCh:
+		err := wait(res).err
+		silent := err == nil || errors.Is(err, context.Canceled)
+		if err == nil {
+			err = ctx.Err()
+		}
+		return nil, &ExitError{Code: signalExitCode(sig), Silent: silent, Err: err}
+	case <-grace:
+		return nil, &ExitError{Code: signalExitCode(sig), Message: fmt.Sprintf("grace period exceeded after %s signal", sig)}
+	case sig = <-sigCh:
+		return nil, &ExitError{Code: signalExitCode(sig), Message: fmt.Sprintf("received second %s signal", sig)}
+	}
+}
+
+// signalExitCode returns the exit code of the given signal (i.e. 130 for SIGINT, 143 for SIGTERM)
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return ExitCodeError
+}
@@ -0,0 +1,120 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+//go:build linux
+
+package gocmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/devfacet/gocmd/v3"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newSignalCmd(gracePeriod time.Duration, stderr *bytes.Buffer, handler func(cmd *gocmd.Cmd, args []string) error) *gocmd.Cmd {
+	cmd, err := gocmd.NewCmd(gocmd.Options{
+		Name: "test",
+		Flags: &struct {
+			Wait struct{} `command:"wait"`
+		}{},
+		HandleSignals: true,
+		GracePeriod:   gracePeriod,
+		Stderr:        stderr,
+	})
+	So(err, ShouldBeNil)
+	cmd.HandleFlag("Wait", handler)
+	So(cmd.Parse([]string{"./app", "wait"}), ShouldBeNil)
+	return cmd
+}
+
+func sendSignal(sig syscall.Signal, started <-chan struct{}) {
+	go func() {
+		<-started
+		syscall.Kill(syscall.Getpid(), sig)
+	}()
+}
+
+func TestCmd_Run_signals(t *testing.T) {
+	Convey("should cancel the context on SIGINT", t, func() {
+		var stderr bytes.Buffer
+		started := make(chan struct{})
+		var ctxErr error
+		cmd := newSignalCmd(0, &stderr, func(cmd *gocmd.Cmd, args []string) error {
+			close(started)
+			<-cmd.Context().Done()
+			ctxErr = cmd.Context().Err()
+			return nil
+		})
+		sendSignal(syscall.SIGINT, started)
+		So(cmd.Run(context.Background()), ShouldEqual, 130)
+		So(errors.Is(ctxErr, context.Canceled), ShouldBeTrue)
+		So(stderr.String(), ShouldBeEmpty)
+	})
+
+	Convey("should re-raise the handler panics in the caller goroutine", t, func() {
+		var stderr bytes.Buffer
+		cmd := newSignalCmd(0, &stderr, func(cmd *gocmd.Cmd, args []string) error {
+			panic("boom")
+		})
+		So(func() { cmd.Run(context.Background()) }, ShouldPanicWith, "boom")
+	})
+
+	Convey("should print the handler error after SIGTERM", t, func() {
+		var stderr bytes.Buffer
+		started := make(chan struct{})
+		cmd := newSignalCmd(0, &stderr, func(cmd *gocmd.Cmd, args []string) error {
+			close(started)
+			<-cmd.Context().Done()
+			return errors.New("cleanup failed")
+		})
+		sendSignal(syscall.SIGTERM, started)
+		So(cmd.Run(context.Background()), ShouldEqual, 143)
+		So(stderr.String(), ShouldEqual, "cleanup failed\n")
+	})
+
+	Convey("should stop waiting after the grace period", t, func() {
+		var stderr bytes.Buffer
+		started := make(chan struct{})
+		done := make(chan struct{})
+		defer close(done)
+		cmd := newSignalCmd(10*time.Millisecond, &stderr, func(cmd *gocmd.Cmd, args []string) error {
+			close(started)
+			<-done
+			return nil
+		})
+		sendSignal(syscall.SIGINT, started)
+		So(cmd.Run(context.Background()), ShouldEqual, 130)
+		So(stderr.String(), ShouldEqual, "grace period exceeded after interrupt signal\n")
+	})
+
+	Convey("should stop waiting on the second signal", t, func() {
+		var stderr bytes.Buffer
+		started := make(chan struct{})
+		done := make(chan struct{})
+		defer close(done)
+		cmd := newSignalCmd(0, &stderr, func(cmd *gocmd.Cmd, args []string) error {
+			close(started)
+			<-cmd.Context().Done()
+			syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+			<-done
+			return nil
+		})
+		sendSignal(syscall.SIGINT, started)
+		So(cmd.Run(context.Background()), ShouldEqual, 143)
+		So(stderr.String(), ShouldEqual, "received second terminated signal\n")
+	})
+
+	Convey("should run without signals", t, func() {
+		var stderr bytes.Buffer
+		cmd := newSignalCmd(0, &stderr, func(cmd *gocmd.Cmd, args []string) error {
+			return nil
+		})
+		So(cmd.Run(context.Background()), ShouldEqual, 0)
+	})
+}
@@ -21,6 +21,9 @@ type Options struct {
 	Content string
 	// Data holds the template data
 	Data interface{}
+	// Funcs holds the additional template functions (i.e. {"upper": strings.ToUpper})
+	// They override the built-in functions (env, time, exec and include) with the same names.
+	Funcs map[string]interface{}
 }
 
 // New returns a new template by the given options
@@ -50,10 +53,11 @@ func New(o Options) (*Template, error) {
 	if t.content != "" {
 		var err error
 		t.template, err = template.New(t.name).Funcs(template.FuncMap{
-			"env":  tplFuncEnv,
-			"time": tplFuncTime,
-			"exec": tplFuncExec,
-		}).Parse(t.content)
+			"env":     tplFuncEnv,
+			"time":    tplFuncTime,
+			"exec":    tplFuncExec,
+			"include": t.include,
+		}).Funcs(o.Funcs).Parse(t.content)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse template due to %s", err.Error())
 		}
@@ -90,3 +94,13 @@ func (t *Template) Execute(data interface{}) (string, error) {
 	}
 	return b.String(), nil
 }
+
+// include executes the given named template (see the define action) and returns its content
+// Unlike the template action, the content can be passed to the other functions (i.e. `{{ include "foo" . | upper }}`).
+func (t *Template) include(name string, data interface{}) (string, error) {
+	b := bytes.Buffer{}
+	if err := t.template.ExecuteTemplate(&b, name, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -5,6 +5,7 @@ package template_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -233,4 +234,24 @@ func TestExecute(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(c, ShouldEqual, "hello world")
 	})
+	Convey("should execute the template by the given functions", t, func() {
+		tpl, err := template.New(template.Options{
+			Content: `{{define "name"}}{{.Test}}{{end}}{{include "name" . | upper}}`,
+			Data:    struct{ Test string }{Test: "foo"},
+			Funcs:   map[string]interface{}{"upper": strings.ToUpper},
+		})
+		So(err, ShouldBeNil)
+		So(tpl, ShouldNotBeNil)
+		c, err := tpl.Execute(nil)
+		So(err, ShouldBeNil)
+		So(c, ShouldEqual, "FOO")
+
+		tpl, err = template.New(template.Options{
+			Content: `{{include "missing" .}}`,
+		})
+		So(err, ShouldBeNil)
+		So(tpl, ShouldNotBeNil)
+		_, err = tpl.Execute(nil)
+		So(err, ShouldNotBeNil)
+	})
 }
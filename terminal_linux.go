@@ -0,0 +1,36 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+//go:build linux
+
+package gocmd
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal returns whether the given file is a terminal or not
+// Other character devices (i.e. /dev/null) are not terminals.
+func isTerminal(f *os.File) bool {
+	var t syscall.Termios
+	_, _, e := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&t)))
+	return e == 0
+}
+
+// disableEcho disables the input echo of the given terminal and returns a function for restoring it
+func disableEcho(f *os.File) (func(), error) {
+	var t syscall.Termios
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&t))); e != 0 {
+		return nil, e
+	}
+	old := t
+	t.Lflag &^= syscall.ECHO
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&t))); e != 0 {
+		return nil, e
+	}
+	return func() {
+		syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&old)))
+	}, nil
+}
@@ -0,0 +1,26 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package gocmd
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal returns whether the given file is a character device or not
+// Terminals can't be distinguished from the other character devices on this platform.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// disableEcho is not supported on this platform
+func disableEcho(f *os.File) (func(), error) {
+	return nil, errors.New("not supported")
+}
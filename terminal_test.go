@@ -0,0 +1,27 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package gocmd
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsTerminal(t *testing.T) {
+	Convey("should not treat the other character devices as terminals", t, func() {
+		f, err := os.Open(os.DevNull)
+		So(err, ShouldBeNil)
+		defer f.Close()
+		So(isTerminal(f), ShouldBeFalse)
+
+		f, err = os.Open("terminal_test.go")
+		So(err, ShouldBeNil)
+		defer f.Close()
+		So(isTerminal(f), ShouldBeFalse)
+	})
+}
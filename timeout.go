@@ -0,0 +1,50 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is the error that is returned when the handlers exceed the command timeout (see Cmd.Timeout)
+var ErrTimeout = errors.New("command timed out")
+
+// Timeout returns the handler timeout of the command
+// The timeout tags (i.e. `timeout:"30s"`) are inherited by the subcommands and the most specific one is used.
+// The value of the `--timeout` string flag (i.e. `--timeout=1m`) overrides it only when the command
+// or one of its parents has a timeout tag so the unrelated timeout flags are not affected.
+// It returns zero when there is no timeout.
+func (cmd *Cmd) Timeout() (time.Duration, error) {
+	// Init vars
+	var result time.Duration
+	for f := cmd.deepestCommand(); f != nil; f = cmd.parentFlag(f) {
+		if d := f.Timeout(); d > 0 {
+			result = d
+			break
+		}
+	}
+	if result == 0 {
+		return 0, nil
+	}
+
+	// Timeout flag
+	if f := cmd.flagSet.FlagByArg("timeout", ""); f != nil {
+		if v, ok := f.Value().(string); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return 0, &ExitError{Code: ExitCodeUsage, Message: fmt.Sprintf("invalid timeout value %s", v)}
+			}
+			return d, nil
+		}
+	}
+
+	return result, nil
+}
+
+// timeoutError returns the timeout error by the given timeout
+func timeoutError(timeout time.Duration) error {
+	return &ExitError{Code: ExitCodeTimeout, Message: fmt.Sprintf("%s after %s", ErrTimeout, timeout), Err: ErrTimeout}
+}
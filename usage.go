@@ -0,0 +1,347 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devfacet/gocmd/v3/flagset"
+	"github.com/devfacet/gocmd/v3/table"
+	"github.com/devfacet/gocmd/v3/template"
+)
+
+// DefaultUsageTemplate is the default template of the usage content (see Usage)
+// Besides the built-in template functions, usage templates can use the `table` function
+// that aligns the given lines by their tab separated columns and the `indent` function
+// that returns two spaces for each nesting level.
+const DefaultUsageTemplate = `Usage: {{ .Synopsis }}
+
+{{ with .Description }}{{ . }}
+
+{{ end }}{{ table (include "usage-rows" .) }}
+{{- define "usage-rows" }}{{ if .Command }}{{ template "usage-command-rows" . }}{{ else }}{{ template "usage-app-rows" . }}{{ end }}{{ end }}
+{{- define "usage-app-rows" }}
+{{- if .Flags }}Options:
+{{ range .Flags }}{{ template "usage-flag" . }}{{ end }}{{ " " }}
+{{ end }}
+{{- if .Commands }}Commands:
+{{ range .Commands }}{{ template "usage-command-tree" . }}{{ end }}
+{{- with .HelpCommand }}  {{ . }} [command...] {{ "\t" }}Display the usage of a command
+{{ end }}{{ end }}
+{{- end }}
+{{- define "usage-command-rows" }}
+{{- if .Flags }}Options:
+{{ range .Flags }}  {{ .Arg }} {{ "\t" }}{{ .Help }}
+{{ end }}{{ end }}
+{{- if .GlobalFlags }}{{ if .Flags }}{{ " " }}
+{{ end }}Global Options:
+{{ range .GlobalFlags }}  {{ .Arg }} {{ "\t" }}{{ .Help }}
+{{ end }}{{ end }}
+{{- if .Commands }}{{ if or .Flags .GlobalFlags }}{{ " " }}
+{{ end }}Commands:
+{{ range .Commands }}  {{ .Command }} {{ "\t" }}{{ .Description }}
+{{ end }}{{ end }}
+{{- end }}
+{{- define "usage-flag" }}{{ indent .Level }}{{ .Arg }} {{ "\t" }}{{ .Help }}
+{{ end }}
+{{- define "usage-command-tree" }}{{ indent .Level }}{{ .Command }} {{ "\t" }}{{ .Description }}
+{{ range .Items }}{{ with .Flag }}{{ template "usage-flag" . }}{{ end }}{{ with .Command }}{{ template "usage-command-tree" . }}{{ end }}{{ end }}
+{{- end }}`
+
+// Usage represents the data of the usage templates
+// It's the usage of the app when Command is nil, otherwise the usage of the command.
+type Usage struct {
+	// Name is the app name
+	Name string
+	// Version is the app version
+	Version string
+	// Description is the description of the app or the command
+	Description string
+	// Synopsis is the usage line without the "Usage:" heading (i.e. `app math pow [options...]`)
+	Synopsis string
+	// Command is the command of the usage
+	Command *UsageCommand
+	// Flags are the flags of the app or the command
+	Flags []*UsageFlag
+	// GlobalFlags are the inherited global flags of the command
+	GlobalFlags []*UsageFlag
+	// Commands are the commands of the app or the subcommands of the command
+	Commands []*UsageCommand
+	// HelpCommand is the name of the built-in help command if it's enabled (see Options.HelpCommand)
+	HelpCommand string
+}
+
+// UsageCommand represents a command of the usage data
+type UsageCommand struct {
+	// Name is the field name of the command (i.e. Pow)
+	Name string
+	// Path is the path of the command (i.e. Math.Pow)
+	Path string
+	// Command is the command name (i.e. pow)
+	Command string
+	// Line is the command names from the app (i.e. `math pow`)
+	Line        string
+	Description string
+	Required    bool
+	// Level is the nesting level of the command (i.e. 1 for the app commands)
+	Level int
+	// Flags are the flags of the command
+	Flags []*UsageFlag
+	// Commands are the subcommands of the command
+	Commands []*UsageCommand
+	// Items are the flags and the subcommands of the command by their definition order
+	Items []*UsageItem
+}
+
+// UsageItem represents a flag or a subcommand of a usage command
+type UsageItem struct {
+	Flag    *UsageFlag
+	Command *UsageCommand
+}
+
+// UsageFlag represents a flag of the usage data
+// Default values of the secret flags are masked (see flagset.SecretMask) and the tabs of the descriptions
+// are replaced by spaces since they separate the table columns.
+type UsageFlag struct {
+	// Name is the field name of the flag (i.e. Base)
+	Name string
+	// Path is the path of the flag (i.e. Math.Pow.Base)
+	Path  string
+	Short string
+	Long  string
+	// Arg is the formatted short and long arguments (i.e. `-b, --base`)
+	// Long only arguments are prefixed by spaces for aligning them with the short ones.
+	Arg         string
+	Type        string
+	Default     string
+	Env         string
+	Description string
+	// Help is the description with the default value and the environment variable (i.e. `Base (default 2)`)
+	Help     string
+	Required bool
+	Global   bool
+	Secret   bool
+	// Level is the nesting level of the flag (i.e. 1 for the app flags)
+	Level int
+}
+
+// usageTemplateFuncs returns the functions of the usage templates
+func usageTemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"table": func(s string) string {
+			if s == "" {
+				return ""
+			}
+			t := table.New(table.Options{})
+			for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+				t.AddRow(strings.Split(line, "\t")...)
+			}
+			return t.FormattedData()
+		},
+		"indent": func(level int) string {
+			return strings.Repeat("  ", level)
+		},
+	}
+}
+
+// usageCell returns the given text by replacing its tabs with spaces since the tabs separate the table columns
+func usageCell(s string) string {
+	return strings.ReplaceAll(s, "\t", " ")
+}
+
+// initUsageTemplates parses the usage templates by the given options
+// The app template is stored by the empty path and the command templates by their paths (i.e. Math.Pow).
+func (cmd *Cmd) initUsageTemplates(o Options) error {
+	// Init vars
+	cmd.usageTemplates = map[string]*template.Template{}
+	content := o.UsageTemplate
+	if content == "" {
+		content = DefaultUsageTemplate
+	}
+
+	// App template
+	tpl, err := template.New(template.Options{Name: "usage", Content: content, Funcs: usageTemplateFuncs()})
+	if err != nil {
+		return err
+	}
+	cmd.usageTemplates[""] = tpl
+
+	// Command templates
+	for path, content := range o.UsageTemplates {
+		tpl, err := template.New(template.Options{Name: "usage " + path, Content: content, Funcs: usageTemplateFuncs()})
+		if err != nil {
+			return err
+		}
+		cmd.usageTemplates[path] = tpl
+	}
+
+	return nil
+}
+
+// checkUsageTemplates returns an error if a command template has an unknown or a non-command path
+// (i.e. `unknown command Math.Pw for usage template (did you mean Math.Pow?)`).
+func (cmd *Cmd) checkUsageTemplates() error {
+	// Init vars
+	var paths []string
+	for _, flag := range cmd.flagSet.Flags() {
+		if flag.Kind() == "command" {
+			paths = append(paths, cmd.flagPath(flag))
+		}
+	}
+	var keys []string
+	for k := range cmd.options.UsageTemplates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Iterate over the paths of the templates
+	for _, k := range keys {
+		flag := cmd.flagSet.FlagByName(k)
+		if flag != nil && flag.Kind() == "command" {
+			continue
+		} else if flag != nil {
+			return fmt.Errorf("flag %s is not a command for usage template", k)
+		} else if s := suggestName(k, paths); s != "" {
+			return fmt.Errorf("unknown command %s for usage template (did you mean %s?)", k, s)
+		}
+		return fmt.Errorf("unknown command %s for usage template", k)
+	}
+
+	return nil
+}
+
+// renderUsage returns the usage content of the given command flag or the app if it's nil
+func (cmd *Cmd) renderUsage(flag *flagset.Flag) (string, error) {
+	// Init vars
+	tpl := cmd.usageTemplates[""]
+	if flag != nil {
+		if v, ok := cmd.usageTemplates[cmd.flagPath(flag)]; ok {
+			tpl = v
+		}
+	}
+	if tpl == nil {
+		return "", nil
+	}
+
+	return tpl.Execute(cmd.usage(flag))
+}
+
+// usage returns the usage data of the given command flag or the app if it's nil
+func (cmd *Cmd) usage(flag *flagset.Flag) *Usage {
+	// Init vars
+	result := Usage{
+		Name:        cmd.name,
+		Version:     cmd.version,
+		Description: cmd.description,
+	}
+	if cmd.helpCommandEnabled() {
+		result.HelpCommand = HelpCommand
+	}
+	hasOpt := false
+	hasCmd := false
+	var flags []*UsageFlag
+	commands := map[int]*UsageCommand{}
+	var appCommands []*UsageCommand
+
+	// Iterate over the usage items (commands and their flags are already sorted)
+	for _, v := range cmd.usageItems("", -1, 0) {
+		f := cmd.flagByID(v.flagID)
+		if f == nil {
+			continue
+		}
+		item := UsageItem{}
+		if v.kind == "command" {
+			hasCmd = true
+			uc := UsageCommand{
+				Name:        f.Name(),
+				Path:        cmd.flagPath(f),
+				Command:     f.Command(),
+				Line:        cmd.commandLine(f),
+				Description: usageCell(f.Description()),
+				Required:    f.Required(),
+				Level:       v.level,
+			}
+			commands[f.ID()] = &uc
+			item.Command = &uc
+		} else if v.kind == "arg" {
+			hasOpt = true
+			uf := UsageFlag{
+				Name:        f.Name(),
+				Path:        cmd.flagPath(f),
+				Short:       f.Short(),
+				Long:        f.Long(),
+				Arg:         v.left,
+				Type:        f.ValueType(),
+				Default:     f.ValueDefault(),
+				Env:         f.Env(),
+				Description: usageCell(f.Description()),
+				Help:        usageCell(v.right),
+				Required:    f.Required(),
+				Global:      f.Global(),
+				Secret:      f.Secret(),
+				Level:       v.level,
+			}
+			if uf.Secret && uf.Default != "" {
+				uf.Default = flagset.SecretMask
+			}
+			item.Flag = &uf
+		}
+
+		// Add the item to its parent
+		if p, ok := commands[v.parentID]; ok {
+			p.Items = append(p.Items, &item)
+			if item.Command != nil {
+				p.Commands = append(p.Commands, item.Command)
+			} else if item.Flag != nil {
+				p.Flags = append(p.Flags, item.Flag)
+			}
+		} else if v.parentID == -1 {
+			if item.Command != nil {
+				appCommands = append(appCommands, item.Command)
+			} else if item.Flag != nil {
+				flags = append(flags, item.Flag)
+			}
+		}
+	}
+
+	// App usage
+	if flag == nil {
+		result.Synopsis = cmd.name
+		if hasOpt {
+			result.Synopsis += " [options...]"
+		}
+		if hasCmd {
+			result.Synopsis += " COMMAND [options...]"
+		}
+		result.Flags = flags
+		result.Commands = appCommands
+		return &result
+	}
+
+	// Command usage
+	uc, ok := commands[flag.ID()]
+	if !ok {
+		return &result
+	}
+	result.Description = uc.Description
+	result.Command = uc
+	result.Flags = uc.Flags
+	result.Commands = uc.Commands
+	for _, v := range flags {
+		if v.Global {
+			result.GlobalFlags = append(result.GlobalFlags, v)
+		}
+	}
+	result.Synopsis = fmt.Sprintf("%s %s", cmd.name, uc.Line)
+	if len(result.Flags) > 0 || len(result.GlobalFlags) > 0 {
+		result.Synopsis += " [options...]"
+	}
+	if len(result.Commands) > 0 {
+		result.Synopsis += " COMMAND [options...]"
+	}
+
+	return &result
+}
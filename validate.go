@@ -0,0 +1,143 @@
+// gocmd
+// For the full copyright and license information, please view the LICENSE.txt file.
+
+package gocmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateHandlers returns the errors of the registered handlers those have unknown flag names
+// The errors contain the closest flag names if any (i.e. `unknown flag Math.Sqr for handler (did you mean Math.Sqrt?)`).
+// App hooks (i.e. empty flag name) are skipped.
+func (cmd *Cmd) ValidateHandlers() []error {
+	return cmd.validateHandlers(true)
+}
+
+// validateHandlers returns the errors of the handlers those have unknown flag names
+// The global handlers (see HandleFlag) are skipped unless the global argument is true.
+func (cmd *Cmd) validateHandlers(global bool) []error {
+	// Init vars
+	var result []error
+	var paths []string
+	for _, flag := range cmd.flagSet.Flags() {
+		paths = append(paths, cmd.flagPath(flag))
+	}
+
+	// Iterate over the handlers
+	dup := map[string]bool{}
+	for _, v := range cmd.handlers {
+		if v.name == "" || (v.global && !global) || dup[v.name] || cmd.flagSet.FlagByName(v.name) != nil {
+			continue
+		}
+		dup[v.name] = true
+		if s := suggestName(v.name, paths); s != "" {
+			result = append(result, fmt.Errorf("unknown flag %s for handler (did you mean %s?)", v.name, s))
+		} else {
+			result = append(result, fmt.Errorf("unknown flag %s for handler", v.name))
+		}
+	}
+
+	return result
+}
+
+// missingHandlers returns the command lines of the leaf commands those have no handler (i.e. `math sqrt`)
+func (cmd *Cmd) missingHandlers() []string {
+	// Init vars
+	var result []string
+	handled := map[string]bool{}
+	for _, v := range cmd.handlers {
+		if v.kind == HandlerKindRun {
+			handled[v.name] = true
+		}
+	}
+	for _, v := range cmd.runnerHandlers() {
+		handled[v.name] = true
+	}
+	parents := map[int]bool{}
+	for _, flag := range cmd.flagSet.Flags() {
+		if flag.Kind() == "command" {
+			parents[flag.ParentID()] = true
+		}
+	}
+
+	// Iterate over the commands
+	for _, flag := range cmd.flagSet.Flags() {
+		if flag.Kind() == "command" && !parents[flag.ID()] && !handled[cmd.flagPath(flag)] {
+			result = append(result, cmd.commandLine(flag))
+		}
+	}
+
+	return result
+}
+
+// checkHandlers checks the handlers by the SkipHandlerValidation and WarnMissingHandlers options
+// It prints the warnings by the logger and returns the first error of the command handlers.
+func (cmd *Cmd) checkHandlers() error {
+	if cmd.options.WarnMissingHandlers {
+		for _, v := range cmd.missingHandlers() {
+			cmd.logger.Printf("warning: command %s has no handler\n", v)
+		}
+	}
+	if !cmd.options.SkipHandlerValidation {
+		if errs := cmd.validateHandlers(false); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	return nil
+}
+
+// suggestName returns the closest name to the given name or returns empty string if there is no close one
+func suggestName(name string, names []string) string {
+	// Init vars
+	result := ""
+	best := len(name)/3 + 1 // maximum distance
+	if best > 3 {
+		best = 3
+	}
+
+	// Iterate over the names
+	for _, v := range names {
+		if strings.EqualFold(v, name) {
+			return v
+		}
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(v)); d <= best {
+			if d < best || result == "" {
+				result = v
+				best = d
+			}
+		}
+	}
+
+	return result
+}
+
+// levenshtein returns the edit distance between the given strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// minInt returns the minimum of the given integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}